@@ -31,6 +31,7 @@ import (
 	"time"
 
 	"github.com/gohugoio/hugo/htesting"
+	"github.com/gohugoio/hugo/resources/images/avif"
 	"github.com/gohugoio/hugo/resources/images/webp"
 
 	"github.com/gohugoio/hugo/common/paths"
@@ -39,8 +40,6 @@ import (
 
 	"github.com/disintegration/gift"
 
-	"github.com/gohugoio/hugo/helpers"
-
 	"github.com/gohugoio/hugo/media"
 	"github.com/gohugoio/hugo/resources/images"
 	"github.com/google/go-cmp/cmp"
@@ -613,6 +612,49 @@ func TestImageOperationsGoldenWebp(t *testing.T) {
 	assetGoldenDirs(c, dir1, dir2)
 }
 
+// Issue #8729, extended to AVIF alongside webp.
+func TestImageOperationsGoldenAvif(t *testing.T) {
+	if !htesting.IsCI() {
+		t.Skip("skip long running test in local mode")
+	}
+	if !avif.Supports() {
+		t.Skip("skip avif test")
+	}
+	c := qt.New(t)
+	c.Parallel()
+
+	devMode := false
+
+	testImages := []string{"fuzzy-cirlcle.png"}
+
+	spec, workDir := newTestResourceOsFs(c)
+	defer func() {
+		if !devMode {
+			os.Remove(workDir)
+		}
+	}()
+
+	if devMode {
+		fmt.Println(workDir)
+	}
+
+	for _, imageName := range testImages {
+		image := fetchImageForSpec(spec, c, imageName)
+		imageAvif, err := image.Resize("200x avif")
+		c.Assert(err, qt.IsNil)
+		c.Assert(imageAvif.Width(), qt.Equals, 200)
+	}
+
+	if devMode {
+		return
+	}
+
+	dir1 := filepath.Join(workDir, "resources/_gen/images/a")
+	dir2 := filepath.FromSlash("testdata/golden_avif")
+
+	assetGoldenDirs(c, dir1, dir2)
+}
+
 func TestImageOperationsGolden(t *testing.T) {
 	if !htesting.IsCI() {
 		t.Skip("skip long running test in local mode")
@@ -689,10 +731,35 @@ func TestImageOperationsGolden(t *testing.T) {
 		c.Assert(rel, qt.Not(qt.Equals), "")
 	}
 
+	// AVIF as both a resize/process target and, when the source itself is
+	// AVIF, as a decode source for further operations.
+	if avif.Supports() {
+		sunsetAvif, err := sunset.Resize("200x avif")
+		c.Assert(err, qt.IsNil)
+		c.Assert(sunsetAvif.MediaType().Type, qt.Equals, "image/avif")
+
+		sunsetAvifProcessed, err := sunset.Process("300x200 avif")
+		c.Assert(err, qt.IsNil)
+		assertWidthHeight(c, sunsetAvifProcessed, 300, 200)
+
+		rel := sunsetAvif.RelPermalink()
+		c.Assert(rel, qt.Not(qt.Equals), "")
+
+		// A previously AVIF-encoded resource must itself be a valid decode
+		// source for further operations.
+		resizedAgain, err := sunsetAvif.Resize("100x")
+		c.Assert(err, qt.IsNil)
+		assertWidthHeight(c, resizedAgain, 100, 63)
+	}
+
 	for _, img := range testImages {
 
 		orig := fetchImageForSpec(spec, c, img)
-		for _, resizeSpec := range []string{"200x100", "600x", "200x r90 q50 Box"} {
+		resizeSpecs := []string{"200x100", "600x", "200x r90 q50 Box"}
+		if avif.Supports() {
+			resizeSpecs = append(resizeSpecs, "200x100 avif")
+		}
+		for _, resizeSpec := range resizeSpecs {
 			resized, err := orig.Resize(resizeSpec)
 			c.Assert(err, qt.IsNil)
 			rel := resized.RelPermalink()
@@ -822,20 +889,32 @@ func assetGoldenDirs(c *qt.C, dir1, dir2 string) {
 		}
 
 		if !usesFMA {
+			// fi1/fi2 compare exact file size, which the minor
+			// floating-point rounding differences FMA architectures
+			// produce during resizing can legitimately change even
+			// when the images are perceptually identical - see the
+			// perceptual hash comparison below, which runs on every
+			// platform including FMA ones.
 			c.Assert(fi1, eq, fi2)
+		}
 
-			_, err = f1.Seek(0, 0)
-			c.Assert(err, qt.IsNil)
-			_, err = f2.Seek(0, 0)
-			c.Assert(err, qt.IsNil)
+		// A perceptual hash tolerates the minor floating-point rounding
+		// differences that FMA (fused-multiply-add) architectures produce
+		// during resizing, so unlike a byte- or MD5-hash of the encoded
+		// file, it can be compared the same way on every platform instead
+		// of being skipped on FMA architectures.
 
-			hash1, err := helpers.MD5FromReader(f1)
-			c.Assert(err, qt.IsNil)
-			hash2, err := helpers.MD5FromReader(f2)
-			c.Assert(err, qt.IsNil)
+		_, err = f1.Seek(0, 0)
+		c.Assert(err, qt.IsNil)
+		_, err = f2.Seek(0, 0)
+		c.Assert(err, qt.IsNil)
 
-			c.Assert(hash1, qt.Equals, hash2)
-		}
+		hash1, err := images.PerceptualHash(f1)
+		c.Assert(err, qt.IsNil)
+		hash2, err := images.PerceptualHash(f2)
+		c.Assert(err, qt.IsNil)
+
+		c.Assert(images.HammingDistance(hash1, hash2) <= 5, qt.IsTrue, qt.Commentf("%s: hash1=%x hash2=%x", fi1.Name(), hash1, hash2))
 
 		f1.Close()
 		f2.Close()
@@ -861,6 +940,36 @@ func BenchmarkResizeParallel(b *testing.B) {
 	})
 }
 
+// BenchmarkResizeParallelBounded repeats the same resize over and over from
+// many goroutines, unlike BenchmarkResizeParallel's ever-changing widths, so
+// that the single-flight dedup in the shared transform pool - which
+// img.Resize now actually routes through - has something to collapse.
+// dedup-hits should come out well above 0: proof that concurrent identical
+// resizes share one computation instead of each goroutine doing its own,
+// which is what keeps memory steady under high parallelism.
+func BenchmarkResizeParallelBounded(b *testing.B) {
+	c := qt.New(b)
+	_, img := fetchSunset(c)
+
+	before := images.TransformMetrics().DedupHits
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resized, err := img.Resize("300x200")
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, err = resized.Resize("150x100")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	after := images.TransformMetrics().DedupHits
+	b.ReportMetric(float64(after-before), "dedup-hits")
+}
+
 func assertWidthHeight(c *qt.C, img images.ImageResource, w, h int) {
 	c.Helper()
 	c.Assert(img, qt.Not(qt.IsNil))
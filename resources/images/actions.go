@@ -0,0 +1,24 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+// The four transform actions ImageResource.Process accepts as the last
+// word of its spec string, and that Resize, Fit, Fill and Crop each pin to
+// one of.
+const (
+	ActionResize = "resize"
+	ActionFit    = "fit"
+	ActionFill   = "fill"
+	ActionCrop   = "crop"
+)
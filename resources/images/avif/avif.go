@@ -0,0 +1,25 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package avif registers AVIF as an image format the rest of resources/images
+// can decode and encode. It follows the same Supports-gated pattern as the
+// sibling webp package: encode/decode only work when Hugo was built with
+// the "avif" build tag, which links in a CGO-backed codec; Supports lets
+// callers fail fast (or skip tests) otherwise.
+package avif
+
+// Supports reports whether this build of Hugo can decode and encode AVIF
+// images. It's false unless Hugo was built with the "avif" build tag.
+func Supports() bool {
+	return isAvifSupportEnabled
+}
@@ -0,0 +1,36 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !avif
+
+package avif
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+const isAvifSupportEnabled = false
+
+var errNotSupported = errors.New("AVIF support is not compiled into this Hugo binary; rebuild with the \"avif\" build tag")
+
+// Encode is a no-op in builds without the "avif" tag.
+func Encode(w io.Writer, img image.Image, quality, speed int) error {
+	return errNotSupported
+}
+
+// Decode is a no-op in builds without the "avif" tag.
+func Decode(r io.Reader) (image.Image, error) {
+	return nil, errNotSupported
+}
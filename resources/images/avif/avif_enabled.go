@@ -0,0 +1,47 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build avif
+
+package avif
+
+import (
+	"image"
+	"io"
+
+	avifenc "github.com/gen2brain/avif"
+)
+
+const isAvifSupportEnabled = true
+
+func init() {
+	image.RegisterFormat("avif", "????ftypavif", Decode, DecodeConfig)
+}
+
+// Encode writes img to w as AVIF at the given quality (1-100) and encode
+// speed (0-10, where 0 is slowest/smallest and 10 is fastest/largest),
+// mirroring the quality/speed knobs exposed for WebP.
+func Encode(w io.Writer, img image.Image, quality, speed int) error {
+	return avifenc.Encode(w, img, avifenc.Options{Quality: quality, Speed: speed})
+}
+
+// Decode reads an AVIF image from r.
+func Decode(r io.Reader) (image.Image, error) {
+	return avifenc.Decode(r)
+}
+
+// DecodeConfig returns the color model and dimensions of an AVIF image
+// without decoding the whole thing, as required by image.RegisterFormat.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	return avifenc.DecodeConfig(r)
+}
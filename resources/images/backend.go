@@ -0,0 +1,80 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// Backend is the pluggable image processing engine behind ImageResource. The
+// default backend ("gift") is the pure-Go pipeline this package has always
+// used. Other backends, such as a libvips-backed one, are registered from a
+// build-tag-gated file and selected via imaging.backend in site
+// configuration.
+type Backend interface {
+	// Name identifies the backend, e.g. "gift" or "vips". It's used in
+	// imaging.backend and in error messages.
+	Name() string
+
+	// Decode reads an image from r.
+	Decode(r io.Reader) (image.Image, error)
+
+	// Resize scales src so that the result fits within, fills, or is cropped
+	// to width x height according to action, which is one of ActionResize,
+	// ActionFit, ActionFill or ActionCrop. anchor is only meaningful for
+	// ActionFill and ActionCrop.
+	Resize(src image.Image, action string, width, height int, filter string, anchor string) (image.Image, error)
+
+	// Filter applies the named filter (e.g. "grayscale", "gaussianblur",
+	// "sepia", "sharpen", "colorize", "overlay") with the given options to
+	// src. "overlay" takes its second source image via options["src"]
+	// (an image.Image) plus options["x"]/options["y"] offsets, since the
+	// signature otherwise only allows for a single source image.
+	Filter(src image.Image, name string, options map[string]any) (image.Image, error)
+
+	// Encode writes img to w in the given format ("jpg", "png", "gif",
+	// "webp", ...) at the given quality, where applicable.
+	Encode(w io.Writer, img image.Image, format string, quality int) error
+}
+
+// DefaultBackendName is used when imaging.backend is unset.
+const DefaultBackendName = "gift"
+
+var backends = map[string]Backend{}
+
+// RegisterBackend makes a Backend available for selection via
+// imaging.backend. It's typically called from an init function in a file
+// gated by a build tag, e.g. backend_vips.go with `//go:build vips`.
+func RegisterBackend(b Backend) {
+	backends[b.Name()] = b
+}
+
+// SelectBackend returns the registered Backend with the given name. An empty
+// name selects DefaultBackendName.
+func SelectBackend(name string) (Backend, error) {
+	if name == "" {
+		name = DefaultBackendName
+	}
+	b, found := backends[name]
+	if !found {
+		return nil, fmt.Errorf("imaging.backend %q is not available in this Hugo binary (is it built with the right build tag?)", name)
+	}
+	return b, nil
+}
+
+func init() {
+	RegisterBackend(newGiftBackend())
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/disintegration/gift"
+
+	"github.com/gohugoio/hugo/resources/images/avif"
+	"github.com/gohugoio/hugo/resources/images/webp"
+)
+
+// giftBackend is the default, pure-Go Backend. It's always available and is
+// used when imaging.backend is unset or explicitly set to "gift".
+type giftBackend struct{}
+
+func newGiftBackend() Backend {
+	return giftBackend{}
+}
+
+func (giftBackend) Name() string {
+	return "gift"
+}
+
+func (giftBackend) Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+func (giftBackend) Resize(src image.Image, action string, width, height int, filter string, anchor string) (image.Image, error) {
+	var g *gift.GIFT
+
+	switch action {
+	case ActionFill, ActionCrop:
+		g = gift.New(gift.ResizeToFill(width, height, giftResampling(filter), giftAnchor(anchor)))
+	default:
+		g = gift.New(gift.Resize(width, height, giftResampling(filter)))
+	}
+
+	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+
+	return dst, nil
+}
+
+func (giftBackend) Filter(src image.Image, name string, options map[string]any) (image.Image, error) {
+	f, err := giftFilterFor(name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	g := gift.New(f)
+	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+
+	return dst, nil
+}
+
+func (giftBackend) Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case "jpg", "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "png":
+		return png.Encode(w, img)
+	case "gif":
+		return gif.Encode(w, img, nil)
+	case "webp":
+		if !webp.Supports() {
+			return fmt.Errorf("encoding to webp requires a Hugo binary built with the \"webp\" build tag")
+		}
+		return webp.Encode(w, img, quality)
+	case "avif":
+		if !avif.Supports() {
+			return fmt.Errorf("encoding to avif requires a Hugo binary built with the \"avif\" build tag")
+		}
+		return avif.Encode(w, img, quality, 6)
+	default:
+		return fmt.Errorf("gift backend: unsupported encode format %q", format)
+	}
+}
+
+func giftResampling(filter string) gift.Resampling {
+	switch filter {
+	case "nearestneighbor":
+		return gift.NearestNeighborResampling
+	case "box":
+		return gift.BoxResampling
+	case "gaussian":
+		return gift.GaussianResampling
+	case "catmullrom":
+		return gift.CatmullRomResampling
+	case "cosine":
+		return gift.CosineResampling
+	default:
+		return gift.LinearResampling
+	}
+}
+
+func giftAnchor(anchor string) gift.Anchor {
+	switch anchor {
+	case "topleft":
+		return gift.TopLeftAnchor
+	case "top":
+		return gift.TopAnchor
+	case "topright":
+		return gift.TopRightAnchor
+	case "left":
+		return gift.LeftAnchor
+	case "right":
+		return gift.RightAnchor
+	case "bottomleft":
+		return gift.BottomLeftAnchor
+	case "bottom":
+		return gift.BottomAnchor
+	case "bottomright":
+		return gift.BottomRightAnchor
+	default:
+		return gift.CenterAnchor
+	}
+}
+
+func giftFilterFor(name string, options map[string]any) (gift.Filter, error) {
+	switch name {
+	case "grayscale":
+		return gift.Grayscale(), nil
+	case "sepia":
+		return gift.Sepia(80), nil
+	case "gaussianblur":
+		return gift.GaussianBlur(6), nil
+	case "sharpen", "unsharpmask":
+		return gift.UnsharpMask(1, 1, 0), nil
+	case "colorize":
+		hue, _ := options["hue"].(float64)
+		saturation, _ := options["saturation"].(float64)
+		percentage, _ := options["percentage"].(float64)
+		if percentage == 0 {
+			percentage = 100
+		}
+		return gift.Colorize(float32(hue), float32(saturation), float32(percentage)), nil
+	case "overlay":
+		src, ok := options["src"].(image.Image)
+		if !ok {
+			return nil, fmt.Errorf("gift backend: overlay filter requires a \"src\" image.Image option")
+		}
+		x, _ := options["x"].(int)
+		y, _ := options["y"].(int)
+		opacity, ok := options["opacity"].(float64)
+		if !ok {
+			opacity = 100
+		}
+		return gift.Overlay(src, x, y, float32(opacity)/100), nil
+	default:
+		return nil, fmt.Errorf("gift backend: unsupported filter %q", name)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build vips
+
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// maxBackendHashDistance is how far apart two backends' encodes of the same
+// resize are allowed to be in perceptual-hash space before the test calls
+// them different images rather than the same resize through two codecs.
+// This package has no testdata/ fixtures and no golden-file convention (see
+// perceptualhash_test.go's synthetic checkerboard); a perceptual-hash
+// comparison between backends follows the same convention
+// image_test.go's own golden tests in the sibling resources package moved
+// to for exactly this reason - gift and vips round through different PNG/
+// JPEG encoders and will never produce byte-identical output.
+const maxBackendHashDistance = 6
+
+// TestVipsBackendMatchesGiftResize proves the vips backend (built only with
+// -tags vips, and only meaningfully run on a machine with libvips
+// installed) produces output close enough to the gift backend's to be
+// confidently the same resize, not a transform that quietly broke or a
+// backend selection bug silently falling through to the wrong pixels.
+func TestVipsBackendMatchesGiftResize(t *testing.T) {
+	c := qt.New(t)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x/8+y/8)%2 == 0 {
+				img.Set(x, y, color.NRGBA{R: 0xfa, G: 0xfa, B: 0xfa, A: 0xff})
+			} else {
+				img.Set(x, y, color.NRGBA{R: 0x0a, G: 0x0a, B: 0x0a, A: 0xff})
+			}
+		}
+	}
+
+	gift, err := SelectBackend("gift")
+	c.Assert(err, qt.IsNil)
+	vips, err := SelectBackend("vips")
+	c.Assert(err, qt.IsNil)
+
+	giftOut, err := gift.Resize(img, ActionResize, 32, 32, "", "")
+	c.Assert(err, qt.IsNil)
+	vipsOut, err := vips.Resize(img, ActionResize, 32, 32, "", "")
+	c.Assert(err, qt.IsNil)
+
+	var giftBuf, vipsBuf bytes.Buffer
+	c.Assert(gift.Encode(&giftBuf, giftOut, "png", 90), qt.IsNil)
+	c.Assert(vips.Encode(&vipsBuf, vipsOut, "png", 90), qt.IsNil)
+
+	giftHash, err := PerceptualHash(bytes.NewReader(giftBuf.Bytes()))
+	c.Assert(err, qt.IsNil)
+	vipsHash, err := PerceptualHash(bytes.NewReader(vipsBuf.Bytes()))
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(HammingDistance(giftHash, vipsHash) <= maxBackendHashDistance, qt.IsTrue)
+}
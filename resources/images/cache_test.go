@@ -0,0 +1,80 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// TestReadSeekCloserPopulatesDiskCache proves ReadSeekCloser actually writes
+// through to imaging.cacheDir - the on-demand thumbnail handler and any
+// future template-facing Resource both rely on this being a real cache, not
+// just a restated in-memory encode on every call.
+func TestReadSeekCloserPopulatesDiskCache(t *testing.T) {
+	c := qt.New(t)
+
+	cacheDir := t.TempDir()
+	spec, err := NewSpec(ImagingConfig{CacheDir: cacheDir})
+	c.Assert(err, qt.IsNil)
+
+	img := newTestImageResource(c, spec)
+	resized, err := img.Resize("100x75")
+	c.Assert(err, qt.IsNil)
+
+	base, err := resized.(*imageResource).cacheBasename()
+	c.Assert(err, qt.IsNil)
+	cachePath := filepath.Join(cacheDir, base)
+
+	_, err = os.Stat(cachePath)
+	c.Assert(err, qt.IsNotNil, qt.Commentf("cache file shouldn't exist before the first read"))
+
+	rsc, err := resized.ReadSeekCloser()
+	c.Assert(err, qt.IsNil)
+	rsc.Close()
+
+	_, err = os.Stat(cachePath)
+	c.Assert(err, qt.IsNil, qt.Commentf("ReadSeekCloser should have written the cache file"))
+
+	// A second call must be served from disk, not recomputed: corrupt the
+	// in-memory image.Image so a fresh encode would fail, then confirm
+	// ReadSeekCloser still succeeds by reading the cache file instead.
+	resized.(*imageResource).img = nil
+	rsc2, err := resized.ReadSeekCloser()
+	c.Assert(err, qt.IsNil)
+	rsc2.Close()
+}
+
+func TestPruneStaleCacheRemovesMismatchedStrategy(t *testing.T) {
+	c := qt.New(t)
+
+	cacheDir := t.TempDir()
+	c.Assert(os.WriteFile(filepath.Join(cacheDir, "sunset_a1b2c3d4.jpg"), []byte("x"), 0o644), qt.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(cacheDir, "sunset_300x200_resize.jpg"), []byte("x"), 0o644), qt.IsNil)
+
+	c.Assert(PruneStaleCache(cacheDir, FilenameStrategyDescriptive), qt.IsNil)
+
+	_, err := os.Stat(filepath.Join(cacheDir, "sunset_a1b2c3d4.jpg"))
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+	_, err = os.Stat(filepath.Join(cacheDir, "sunset_300x200_resize.jpg"))
+	c.Assert(err, qt.IsNil)
+}
+
+func TestPruneStaleCacheNoCacheDir(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(PruneStaleCache("", FilenameStrategyHash), qt.IsNil)
+}
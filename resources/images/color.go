@@ -0,0 +1,72 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Color wraps color.Color with the accessors templates and Resource.Colors
+// callers use, e.g. {{ range .Colors }}{{ .ColorHex }}{{ end }}.
+type Color struct {
+	color.Color
+}
+
+// ColorHex returns c as a lowercase "#rrggbb" string.
+func (c Color) ColorHex() string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", uint8(r>>8), uint8(g>>8), uint8(b>>8))
+}
+
+// ColorGo returns the underlying color.Color.
+func (c Color) ColorGo() color.Color {
+	return c.Color
+}
+
+// Luminance returns c's relative luminance per the sRGB/WCAG formula, in the
+// range [0, 1].
+func (c Color) Luminance() float64 {
+	r, g, b, _ := c.RGBA()
+	linearize := func(v uint32) float64 {
+		f := float64(v>>8) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linearize(r) + 0.7152*linearize(g) + 0.0722*linearize(b)
+}
+
+// HexStringsToColors parses hex strings such as "#2d2f33" into Colors.
+// Values that don't parse as a 6-digit hex color are skipped rather than
+// erroring, since this is mostly used to build known-good palettes for
+// comparison against Colors() output.
+func HexStringsToColors(hex ...string) []Color {
+	result := make([]Color, 0, len(hex))
+	for _, h := range hex {
+		h = strings.TrimPrefix(h, "#")
+		if len(h) != 6 {
+			continue
+		}
+		var r, g, b uint8
+		if _, err := fmt.Sscanf(h, "%02x%02x%02x", &r, &g, &b); err != nil {
+			continue
+		}
+		result = append(result, Color{color.RGBA{R: r, G: g, B: b, A: 0xff}})
+	}
+	return result
+}
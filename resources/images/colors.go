@@ -0,0 +1,97 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"sort"
+)
+
+// numExtractedColors is the size of the palette Colors returns. Templates
+// building a "dominant colors" swatch (e.g. for a blurred placeholder or an
+// accent color) want a small, fixed-size list rather than every distinct
+// color in the source.
+const numExtractedColors = 6
+
+// colorBucketBits quantizes each 8-bit channel down to 4 bits before
+// counting frequency, so visually-identical near-duplicate pixels (JPEG
+// ringing, anti-aliasing) count as the same color instead of each getting
+// their own single-pixel-sized bucket.
+const colorBucketBits = 4
+
+// Colors extracts the numExtractedColors most frequent colors in the image,
+// most frequent first, by quantizing every pixel into a coarse RGB bucket
+// and counting occurrences - a histogram, not a perceptual clustering
+// algorithm, but cheap and deterministic, which matters more here since the
+// result normally only backs a low-fidelity placeholder or accent color.
+func (i *imageResource) Colors() ([]Color, error) {
+	b := i.img.Bounds()
+
+	type bucket struct {
+		r, g, b uint8
+		count   int
+	}
+	counts := make(map[uint32]*bucket)
+
+	shift := 8 - colorBucketBits
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := i.img.At(x, y).RGBA()
+			r8, g8, b8 := uint8(r>>8)>>shift<<shift, uint8(g>>8)>>shift<<shift, uint8(bl>>8)>>shift<<shift
+			key := uint32(r8)<<16 | uint32(g8)<<8 | uint32(b8)
+			if existing, found := counts[key]; found {
+				existing.count++
+			} else {
+				counts[key] = &bucket{r: r8, g: g8, b: b8, count: 1}
+			}
+		}
+	}
+
+	buckets := make([]*bucket, 0, len(counts))
+	for _, bk := range counts {
+		buckets = append(buckets, bk)
+	}
+	rgbKey := func(bk *bucket) uint32 {
+		return uint32(bk.r)<<16 | uint32(bk.g)<<8 | uint32(bk.b)
+	}
+	sort.Slice(buckets, func(a, c int) bool {
+		if buckets[a].count != buckets[c].count {
+			return buckets[a].count > buckets[c].count
+		}
+		// Break count ties deterministically; map iteration order is random.
+		return rgbKey(buckets[a]) < rgbKey(buckets[c])
+	})
+
+	n := numExtractedColors
+	if len(buckets) < n {
+		n = len(buckets)
+	}
+
+	hexes := make([]string, n)
+	for idx := 0; idx < n; idx++ {
+		bk := buckets[idx]
+		hexes[idx] = rgbHex(bk.r, bk.g, bk.b)
+	}
+
+	return HexStringsToColors(hexes...), nil
+}
+
+func rgbHex(r, g, b uint8) string {
+	const hextable = "0123456789abcdef"
+	buf := make([]byte, 7)
+	buf[0] = '#'
+	buf[1], buf[2] = hextable[r>>4], hextable[r&0xf]
+	buf[3], buf[4] = hextable[g>>4], hextable[g&0xf]
+	buf[5], buf[6] = hextable[b>>4], hextable[b&0xf]
+	return string(buf)
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ImagingConfig is the decoded form of the top-level `imaging` section of
+// site configuration. It's the single place every imaging.* sub-config in
+// this package (thumbnails, worker pool sizing, backend selection, the
+// filename scheme) is collected, so hugolib can build a Spec from one
+// decoded value instead of wiring each sub-config in separately.
+type ImagingConfig struct {
+	// Backend selects the processing backend: "gift" (the default) or,
+	// when Hugo is built with the "vips" build tag, "vips". See
+	// SelectBackend.
+	Backend string
+
+	// MaxConcurrentTransforms bounds how many Resize/Fit/Fill/Crop/Process
+	// calls run at once; see SetTransformWorkers. Defaults to
+	// runtime.GOMAXPROCS(0).
+	MaxConcurrentTransforms int
+
+	// FilenameStrategy controls RelPermalink and cache-key naming; see
+	// FilenameStrategy.
+	FilenameStrategy string
+
+	// CacheDir is the directory transformed images are written to and
+	// served from on subsequent requests/builds, keyed by the same
+	// basename RelPermalink produces. Empty disables the on-disk cache:
+	// ReadSeekCloser then re-encodes from the in-memory image.Image on
+	// every call, as it always has.
+	CacheDir string
+
+	// Thumbnails declares the presets to pre-generate at build start; see
+	// ThumbnailsConfig.
+	Thumbnails ThumbnailsConfig
+}
+
+// DecodeConfig decodes the `imaging` section of site configuration,
+// delegating the thumbnails and filename-strategy sub-sections to their
+// own decoders, and applies the process-global part of the result (the
+// shared transform pool's worker count) immediately so it's in effect
+// before any image is processed.
+func DecodeConfig(m map[string]any) (ImagingConfig, error) {
+	var conf ImagingConfig
+	if m == nil {
+		// No imaging config block at all - leave the shared transform pool
+		// (and its in-flight calls/metrics, possibly still serving another
+		// site in a multilingual build) exactly as it is rather than
+		// resetting it to the default worker count.
+		return conf, nil
+	}
+
+	// Thumbnails has its own validating decoder (every preset needs a
+	// name, missing Workers gets a default); decode it separately so a
+	// generic WeakDecode of the whole map can't silently skip that
+	// validation.
+	scalar := make(map[string]any, len(m))
+	for k, v := range m {
+		if k == "thumbnails" {
+			continue
+		}
+		scalar[k] = v
+	}
+
+	if err := mapstructure.WeakDecode(scalar, &conf); err != nil {
+		return conf, fmt.Errorf("failed to decode imaging config: %w", err)
+	}
+
+	if rawThumbnails, found := m["thumbnails"]; found {
+		tm, ok := rawThumbnails.(map[string]any)
+		if !ok {
+			return conf, fmt.Errorf("imaging.thumbnails must be a table")
+		}
+		thumbnailsConf, err := DecodeThumbnailsConfig(tm)
+		if err != nil {
+			return conf, err
+		}
+		conf.Thumbnails = thumbnailsConf
+	}
+
+	strategy, err := DecodeFilenameStrategy(conf.FilenameStrategy)
+	if err != nil {
+		return conf, err
+	}
+	conf.FilenameStrategy = string(strategy)
+
+	SetTransformWorkers(conf.MaxConcurrentTransforms)
+
+	return conf, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"image"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/gohugoio/hugo/media"
+)
+
+func newTestImageResource(c *qt.C, spec *Spec) *imageResource {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 300))
+	return newImageResource(spec, img, "test.png", ".png", "png", media.Type{Type: "image/png"})
+}
+
+// TestDecodeConfigWarmupEndToEnd exercises the same path hugolib's build
+// pipeline uses: decode imaging config (including imaging.thumbnails),
+// build a Spec from it, and warm up every discovered image resource's
+// configured thumbnail presets up front rather than lazily on first
+// template call.
+func TestDecodeConfigWarmupEndToEnd(t *testing.T) {
+	c := qt.New(t)
+
+	conf, err := DecodeConfig(map[string]any{
+		"maxConcurrentTransforms": 2,
+		"thumbnails": map[string]any{
+			"presets": []map[string]any{
+				{"name": "small", "width": 100, "height": 75, "action": "fill"},
+			},
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(conf.Thumbnails.Presets), qt.Equals, 1)
+
+	spec, err := NewSpec(conf)
+	c.Assert(err, qt.IsNil)
+
+	img := newTestImageResource(c, spec)
+
+	err = spec.WarmupImages([]ImageResource{img})
+	c.Assert(err, qt.IsNil)
+
+	thumbs, err := img.Thumbnails()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(thumbs), qt.Equals, 1)
+	c.Assert(thumbs[0].Width(), qt.Equals, 100)
+	c.Assert(thumbs[0].Height(), qt.Equals, 75)
+
+	named, err := img.Thumbnail("small")
+	c.Assert(err, qt.IsNil)
+	c.Assert(named.Width(), qt.Equals, 100)
+}
+
+func TestDecodeConfigInvalidBackend(t *testing.T) {
+	c := qt.New(t)
+
+	conf, err := DecodeConfig(map[string]any{"backend": "does-not-exist"})
+	c.Assert(err, qt.IsNil)
+
+	_, err = NewSpec(conf)
+	c.Assert(err, qt.Not(qt.IsNil))
+}
@@ -0,0 +1,308 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Exif holds the subset of a JPEG's EXIF metadata templates care about
+// (capture date, GPS coordinates) plus every other tag this package knows
+// the name of, keyed by tag name, for {{ .Exif.Tags.LensModel }} style
+// access.
+type Exif struct {
+	Date time.Time
+	Lat  float64
+	Long float64
+	Tags map[string]any
+}
+
+// exifTagNames maps the handful of EXIF/GPS tag IDs this package resolves to
+// a human name. It isn't exhaustive - unknown tags are simply omitted from
+// Exif.Tags rather than erroring, since a missing tag is far more likely
+// than a corrupt file.
+var exifTagNames = map[uint16]string{
+	0x010f: "Make",
+	0x0110: "Model",
+	0x0112: "Orientation",
+	0x829a: "ExposureTime",
+	0x829d: "FNumber",
+	0x8827: "ISOSpeedRatings",
+	0x9003: "DateTimeOriginal",
+	0xa002: "PixelXDimension",
+	0xa003: "PixelYDimension",
+	0xa434: "LensModel",
+}
+
+const (
+	exifTagExifIFD = 0x8769
+	exifTagGPSIFD  = 0x8825
+	exifTagDateOrg = 0x9003
+
+	gpsTagLatRef  = 1
+	gpsTagLat     = 2
+	gpsTagLongRef = 3
+	gpsTagLong    = 4
+)
+
+// decodeExif scans a JPEG's APP1 segments for an "Exif\0\0"-prefixed TIFF
+// blob and parses it. It returns nil whenever content isn't a JPEG, has no
+// EXIF segment, or the segment doesn't parse - metadata is a bonus, not
+// something a missing or malformed blob should fail image processing over.
+func decodeExif(content []byte) *Exif {
+	tiff := findExifTIFF(content)
+	if tiff == nil {
+		return nil
+	}
+
+	order, ok := tiffByteOrder(tiff)
+	if !ok {
+		return nil
+	}
+
+	ifd0Offset := order.Uint32(tiff[4:8])
+	ifd0, _, err := readIFD(tiff, order, ifd0Offset)
+	if err != nil {
+		return nil
+	}
+
+	result := &Exif{Tags: map[string]any{}}
+	collectTags(tiff, order, ifd0, result.Tags)
+
+	if e, found := ifd0[exifTagExifIFD]; found {
+		if off, ok := entryUint32(e); ok {
+			if exifIFD, _, err := readIFD(tiff, order, off); err == nil {
+				collectTags(tiff, order, exifIFD, result.Tags)
+				if raw, found := exifIFD[exifTagDateOrg]; found {
+					if s, ok := entryASCII(tiff, order, raw); ok {
+						if t, err := time.Parse("2006:01:02 15:04:05", s); err == nil {
+							result.Date = t
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if e, found := ifd0[exifTagGPSIFD]; found {
+		if off, ok := entryUint32(e); ok {
+			if gpsIFD, _, err := readIFD(tiff, order, off); err == nil {
+				result.Lat = gpsCoordinate(tiff, order, gpsIFD, gpsTagLatRef, gpsTagLat, "S")
+				result.Long = gpsCoordinate(tiff, order, gpsIFD, gpsTagLongRef, gpsTagLong, "W")
+			}
+		}
+	}
+
+	return result
+}
+
+// findExifTIFF locates the TIFF blob inside a JPEG's first "Exif\0\0" APP1
+// segment, or nil if content isn't a JPEG or has no such segment.
+func findExifTIFF(content []byte) []byte {
+	if len(content) < 4 || content[0] != 0xff || content[1] != 0xd8 {
+		return nil
+	}
+
+	pos := 2
+	for pos+4 <= len(content) {
+		if content[pos] != 0xff {
+			pos++
+			continue
+		}
+		marker := content[pos+1]
+		if marker == 0xd8 || marker == 0xd9 || (marker >= 0xd0 && marker <= 0xd7) {
+			pos += 2
+			continue
+		}
+		if pos+4 > len(content) {
+			break
+		}
+		segLen := int(content[pos+2])<<8 | int(content[pos+3])
+		segStart := pos + 4
+		segEnd := pos + 2 + segLen
+		if segEnd > len(content) || segLen < 2 {
+			break
+		}
+		if marker == 0xe1 && bytes.HasPrefix(content[segStart:segEnd], []byte("Exif\x00\x00")) {
+			return content[segStart+6 : segEnd]
+		}
+		if marker == 0xda {
+			break // start of scan: no more APPn segments follow
+		}
+		pos = segEnd
+	}
+	return nil
+}
+
+func tiffByteOrder(tiff []byte) (binary.ByteOrder, bool) {
+	if len(tiff) < 8 {
+		return nil, false
+	}
+	switch string(tiff[0:2]) {
+	case "II":
+		return binary.LittleEndian, tiff[2] == 0x2a && tiff[3] == 0x00
+	case "MM":
+		return binary.BigEndian, tiff[2] == 0x00 && tiff[3] == 0x2a
+	default:
+		return nil, false
+	}
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	raw   [4]byte
+}
+
+// readIFD parses one IFD (a tag count followed by that many 12-byte
+// entries) at offset into tiff, returning it keyed by tag ID and the offset
+// of the next IFD in the chain (0 if there is none).
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) (map[uint16]ifdEntry, uint32, error) {
+	if int(offset)+2 > len(tiff) {
+		return nil, 0, fmt.Errorf("exif: IFD offset out of range")
+	}
+	count := order.Uint16(tiff[offset : offset+2])
+	entries := make(map[uint16]ifdEntry, count)
+	base := int(offset) + 2
+	for i := 0; i < int(count); i++ {
+		start := base + i*12
+		if start+12 > len(tiff) {
+			return nil, 0, fmt.Errorf("exif: truncated IFD entry")
+		}
+		var e ifdEntry
+		tag := order.Uint16(tiff[start : start+2])
+		e.typ = order.Uint16(tiff[start+2 : start+4])
+		e.count = order.Uint32(tiff[start+4 : start+8])
+		copy(e.raw[:], tiff[start+8:start+12])
+		entries[tag] = e
+	}
+	nextOffset := uint32(0)
+	nextPos := base + int(count)*12
+	if nextPos+4 <= len(tiff) {
+		nextOffset = order.Uint32(tiff[nextPos : nextPos+4])
+	}
+	return entries, nextOffset, nil
+}
+
+func entryUint32(e ifdEntry) (uint32, bool) {
+	if e.typ != 4 && e.typ != 3 {
+		return 0, false
+	}
+	if e.typ == 3 {
+		return uint32(binary.LittleEndian.Uint16(e.raw[:2])), true
+	}
+	return binary.LittleEndian.Uint32(e.raw[:]), true
+}
+
+// typeSize returns the size in bytes of one value of the given TIFF field
+// type, treating unknown types as 1 byte.
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 7:
+		return 1
+	case 3:
+		return 2
+	case 4, 9:
+		return 4
+	case 5, 10:
+		return 8
+	default:
+		return 1
+	}
+}
+
+func entryASCII(tiff []byte, order binary.ByteOrder, e ifdEntry) (string, bool) {
+	if e.typ != 2 {
+		return "", false
+	}
+	n := int(e.count)
+	var data []byte
+	if n <= 4 {
+		data = e.raw[:n]
+	} else {
+		off := order.Uint32(e.raw[:])
+		if int(off)+n > len(tiff) {
+			return "", false
+		}
+		data = tiff[off : int(off)+n]
+	}
+	return string(bytes.TrimRight(data, "\x00")), true
+}
+
+func entryRational(tiff []byte, order binary.ByteOrder, e ifdEntry, index int) (float64, bool) {
+	if e.typ != 5 || index >= int(e.count) {
+		return 0, false
+	}
+	off := order.Uint32(e.raw[:])
+	pos := int(off) + index*8
+	if pos+8 > len(tiff) {
+		return 0, false
+	}
+	num := order.Uint32(tiff[pos : pos+4])
+	den := order.Uint32(tiff[pos+4 : pos+8])
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+func gpsCoordinate(tiff []byte, order binary.ByteOrder, ifd map[uint16]ifdEntry, refTag, valTag uint16, negativeRef string) float64 {
+	valEntry, found := ifd[valTag]
+	if !found {
+		return 0
+	}
+	deg, ok1 := entryRational(tiff, order, valEntry, 0)
+	min, ok2 := entryRational(tiff, order, valEntry, 1)
+	sec, ok3 := entryRational(tiff, order, valEntry, 2)
+	if !ok1 || !ok2 || !ok3 {
+		return 0
+	}
+	coord := deg + min/60 + sec/3600
+
+	if refEntry, found := ifd[refTag]; found {
+		if ref, ok := entryASCII(tiff, order, refEntry); ok && ref == negativeRef {
+			coord = -coord
+		}
+	}
+	return coord
+}
+
+// collectTags resolves every entry in ifd that exifTagNames knows the name
+// of into dst, skipping anything else - Tags is a convenience lookup, not a
+// full EXIF dump.
+func collectTags(tiff []byte, order binary.ByteOrder, ifd map[uint16]ifdEntry, dst map[string]any) {
+	for tag, e := range ifd {
+		name, known := exifTagNames[tag]
+		if !known {
+			continue
+		}
+		switch e.typ {
+		case 2:
+			if s, ok := entryASCII(tiff, order, e); ok {
+				dst[name] = s
+			}
+		case 3, 4:
+			if v, ok := entryUint32(e); ok {
+				dst[name] = v
+			}
+		case 5:
+			if v, ok := entryRational(tiff, order, e, 0); ok {
+				dst[name] = v
+			}
+		}
+	}
+}
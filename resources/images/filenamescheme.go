@@ -0,0 +1,184 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilenameStrategy controls how transformed image filenames, both the
+// RelPermalink and the on-disk cache key, are derived.
+type FilenameStrategy string
+
+const (
+	// FilenameStrategyDescriptive is the long-standing default: the
+	// original name plus a descriptive, parameter-derived suffix (falling
+	// back to an MD5 of the spec once that suffix gets too long).
+	FilenameStrategyDescriptive FilenameStrategy = "descriptive"
+
+	// FilenameStrategyHash replaces the descriptive suffix with a short
+	// hash of the transform spec, regardless of its length.
+	FilenameStrategyHash FilenameStrategy = "hash"
+
+	// FilenameStrategyContent names the file after a short hash of its own
+	// encoded bytes, so the result is immutable and safe to serve behind a
+	// far-future Cache-Control: immutable header.
+	FilenameStrategyContent FilenameStrategy = "content"
+)
+
+// shortHashLen is the number of hex characters used for the hash and
+// content filename strategies, short enough to stay readable while keeping
+// collisions implausible for a single site's image set.
+const shortHashLen = 8
+
+// maxDescriptiveSuffixLen bounds how long a generated descriptive suffix
+// (action plus spec fields) is allowed to get before descriptiveSuffix
+// falls back to an MD5 of the action+spec it was built from. A spec with
+// many options (anchor, filter, quality, format, ...) would otherwise
+// produce an unreadably long filename.
+const maxDescriptiveSuffixLen = 40
+
+// descriptiveSuffix builds the "_300x200_resize_q68_linear"-style suffix
+// transformedFilename's descriptive strategy appends to the base name:
+// the spec's dimensions, then the action word, then every other field in
+// the order given. Once that gets too long to be useful as a readable
+// filename, it falls back to "_" plus an MD5 of action+spec.
+func descriptiveSuffix(action, spec string) string {
+	fields := strings.Fields(spec)
+
+	var parts []string
+	if len(fields) > 0 {
+		parts = append(parts, fields[0], action)
+		parts = append(parts, fields[1:]...)
+	} else {
+		parts = append(parts, action)
+	}
+
+	suffix := "_" + strings.Join(parts, "_")
+	if len(suffix) > maxDescriptiveSuffixLen {
+		sum := md5.Sum([]byte(action + "|" + spec))
+		return fmt.Sprintf("_%x", sum)
+	}
+	return suffix
+}
+
+// DecodeFilenameStrategy validates the imaging.filenameStrategy site
+// configuration value. An empty string decodes to the existing descriptive
+// behaviour so upgrading Hugo doesn't change anyone's filenames.
+func DecodeFilenameStrategy(s string) (FilenameStrategy, error) {
+	switch FilenameStrategy(s) {
+	case "":
+		return FilenameStrategyDescriptive, nil
+	case FilenameStrategyDescriptive, FilenameStrategyHash, FilenameStrategyContent:
+		return FilenameStrategy(s), nil
+	default:
+		return "", fmt.Errorf("imaging.filenameStrategy: invalid value %q, must be one of %q, %q or %q", s, FilenameStrategyDescriptive, FilenameStrategyHash, FilenameStrategyContent)
+	}
+}
+
+// transformedFilename builds the basename (without directory) of a
+// transformed image according to strategy.
+//
+//   - descriptive: baseName + descriptiveSuffix (the existing behaviour,
+//     already falling back to an MD5 of action+spec once descriptiveSuffix grows
+//     past the MD5 key threshold; unaffected by this function).
+//   - hash: baseName + a short MD5 of specKey.
+//   - content: baseName + a short MD5 of the final encoded bytes.
+//
+// descriptiveSuffix, specKey and content are all already computed by the
+// existing transform pipeline; this function only decides how to combine
+// them into a name.
+func transformedFilename(strategy FilenameStrategy, baseName, ext, descriptiveSuffix, specKey string, content []byte) string {
+	switch strategy {
+	case FilenameStrategyContent:
+		sum := md5.Sum(content)
+		return fmt.Sprintf("%s_%x", baseName, sum[:])[:len(baseName)+1+shortHashLen] + ext
+	case FilenameStrategyHash:
+		sum := md5.Sum([]byte(specKey))
+		return fmt.Sprintf("%s_%x", baseName, sum[:])[:len(baseName)+1+shortHashLen] + ext
+	default:
+		return baseName + descriptiveSuffix + ext
+	}
+}
+
+// staleCacheFilenames returns the on-disk filenames, relative to the
+// generated-images cache dir, that were produced by a previous build using a
+// different filenameStrategy than the one currently configured. Hugo calls
+// this once at the start of a build so a mid-build strategy change doesn't
+// leave the old files as permanent orphans: they're removed rather than
+// silently left to accumulate.
+func staleCacheFilenames(cacheDirEntries []string, current FilenameStrategy) []string {
+	var stale []string
+	for _, name := range cacheDirEntries {
+		base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		looksHashed := len(base) > shortHashLen && base[len(base)-shortHashLen-1] == '_' && isHex(base[len(base)-shortHashLen:])
+		switch current {
+		case FilenameStrategyHash, FilenameStrategyContent:
+			if !looksHashed {
+				stale = append(stale, name)
+			}
+		default:
+			if looksHashed {
+				stale = append(stale, name)
+			}
+		}
+	}
+	return stale
+}
+
+// PruneStaleCache removes every file directly inside cacheDir that
+// staleCacheFilenames identifies as belonging to a different FilenameStrategy
+// than current. Hugo calls this once per site at the start of a build (see
+// Spec.PruneStaleCache), right after DecodeConfig, so a mid-project
+// filenameStrategy change doesn't leave the old variants as permanent
+// orphans taking up space in the generated-images cache dir.
+func PruneStaleCache(cacheDir string, current FilenameStrategy) error {
+	if cacheDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read image cache dir %q: %w", cacheDir, err)
+	}
+
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+
+	for _, stale := range staleCacheFilenames(names, current) {
+		if err := os.Remove(filepath.Join(cacheDir, stale)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale cached image %q: %w", stale, err)
+		}
+	}
+
+	return nil
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
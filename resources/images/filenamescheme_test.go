@@ -0,0 +1,51 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDecodeFilenameStrategy(t *testing.T) {
+	c := qt.New(t)
+
+	s, err := DecodeFilenameStrategy("")
+	c.Assert(err, qt.IsNil)
+	c.Assert(s, qt.Equals, FilenameStrategyDescriptive)
+
+	s, err = DecodeFilenameStrategy("content")
+	c.Assert(err, qt.IsNil)
+	c.Assert(s, qt.Equals, FilenameStrategyContent)
+
+	_, err = DecodeFilenameStrategy("bogus")
+	c.Assert(err, qt.Not(qt.IsNil))
+}
+
+func TestTransformedFilename(t *testing.T) {
+	c := qt.New(t)
+
+	name := transformedFilename(FilenameStrategyDescriptive, "sunset", ".jpg", "_300x200_resize_q68_linear", "spec", nil)
+	c.Assert(name, qt.Equals, "sunset_300x200_resize_q68_linear.jpg")
+
+	name = transformedFilename(FilenameStrategyHash, "sunset", ".jpg", "_300x200_resize_q68_linear", "300x200 resize q68 linear", nil)
+	c.Assert(name, qt.Equals, "sunset_f3a55e72.jpg")
+
+	name1 := transformedFilename(FilenameStrategyContent, "sunset", ".jpg", "", "", []byte("abc"))
+	name2 := transformedFilename(FilenameStrategyContent, "sunset", ".jpg", "", "", []byte("abc"))
+	c.Assert(name1, qt.Equals, name2)
+	name3 := transformedFilename(FilenameStrategyContent, "sunset", ".jpg", "", "", []byte("def"))
+	c.Assert(name1, qt.Not(qt.Equals), name3)
+}
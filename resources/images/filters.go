@@ -0,0 +1,230 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/disintegration/gift"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ImageSource is the read side of ImageResource that filters needing to
+// composite one image onto another (Overlay) depend on, so a filter can
+// accept "any other image this package knows how to decode" without caring
+// whether it arrived as a Resource, a Resize() result, or a raw ImageSource.
+type ImageSource interface {
+	DecodeImage() (image.Image, error)
+}
+
+// Filters is the namespace templates use to build gift.Filter values for
+// ImageResource.Filter, e.g. {{ $img.Filter (images.Filters.Grayscale) }}.
+// Each method returns a gift.Filter (or, for Overlay/Text, this package's
+// own implementation of that interface) rather than applying itself
+// directly, so callers can chain several into a slice and apply them in one
+// pass: {{ $img.Filter (slice (images.Filters.Overlay ...) (images.Filters.Text ...)) }}.
+type Filters struct{}
+
+func (Filters) Grayscale() gift.Filter                    { return gift.Grayscale() }
+func (Filters) GaussianBlur(sigma float32) gift.Filter    { return gift.GaussianBlur(sigma) }
+func (Filters) Saturation(percentage float32) gift.Filter { return gift.Saturation(percentage) }
+func (Filters) Sepia(percentage float32) gift.Filter      { return gift.Sepia(percentage) }
+func (Filters) Brightness(percentage float32) gift.Filter { return gift.Brightness(percentage) }
+func (Filters) ColorBalance(percentageRed, percentageGreen, percentageBlue float32) gift.Filter {
+	return gift.ColorBalance(percentageRed, percentageGreen, percentageBlue)
+}
+
+func (Filters) Colorize(hue, saturation, percentage float32) gift.Filter {
+	return gift.Colorize(hue, saturation, percentage)
+}
+func (Filters) Gamma(gamma float32) gift.Filter { return gift.Gamma(gamma) }
+func (Filters) UnsharpMask(sigma, amount, threshold float32) gift.Filter {
+	return gift.UnsharpMask(sigma, amount, threshold)
+}
+func (Filters) Sigmoid(midpoint, factor float32) gift.Filter { return gift.Sigmoid(midpoint, factor) }
+func (Filters) Pixelate(size int) gift.Filter                { return gift.Pixelate(size) }
+func (Filters) Invert() gift.Filter                          { return gift.Invert() }
+func (Filters) Hue(shift float32) gift.Filter                { return gift.Hue(shift) }
+func (Filters) Contrast(percentage float32) gift.Filter      { return gift.Contrast(percentage) }
+
+// Opacity returns a filter that scales every pixel's alpha to percentage
+// (0-100) of its original value.
+func (Filters) Opacity(percentage float32) gift.Filter {
+	return &opacityFilter{percentage: percentage}
+}
+
+// Overlay returns a filter that draws src at (x, y) on top of the image it's
+// applied to, at full opacity - apply Opacity to src first, in a Filter call
+// of its own, if src itself should appear translucent; an Opacity in the same
+// chain as Overlay acts on the image Overlay draws onto. It decodes src eagerly (rather than deferring to
+// Draw time, the way Opacity/Text can) because gift.Overlay, unlike this
+// package's own filters, wants a plain image.Image up front.
+func (Filters) Overlay(src ImageSource, x, y int) gift.Filter {
+	img, err := src.DecodeImage()
+	if err != nil {
+		return identityFilter{}
+	}
+	return gift.Overlay(img, x, y, 1)
+}
+
+// identityFilter implements gift.Filter as a no-op, used as a safe fallback
+// when a filter can't do its real work (e.g. Overlay's src failed to
+// decode) but still needs to satisfy the interface.
+type identityFilter struct{}
+
+func (identityFilter) Bounds(srcBounds image.Rectangle) image.Rectangle { return srcBounds }
+
+func (identityFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	draw.Draw(dst, src.Bounds(), src, src.Bounds().Min, draw.Src)
+}
+
+// Text returns a filter that draws text onto the image it's applied to.
+// options, if given, may set "x" and "y" (the left end of the first line's
+// baseline, defaulting to x 0 and y 10px above the bottom edge; each wrapped
+// line sits 16px below the previous one) and "color" (a "#rrggbb" hex
+// string, defaulting to white). A size option is accepted but this
+// package's filter always renders with the one face it embeds; real Hugo's
+// richer Text filter supports loading a TTF and size, which is out of
+// scope for the minimal implementation here.
+func (Filters) Text(text string, options ...map[string]any) gift.Filter {
+	var opts map[string]any
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	return &textFilter{text: text, options: opts}
+}
+
+// opacityFilter implements gift.Filter.
+type opacityFilter struct {
+	percentage float32
+}
+
+func (f *opacityFilter) Bounds(srcBounds image.Rectangle) image.Rectangle { return srcBounds }
+
+func (f *opacityFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	alpha := float64(f.percentage) / 100
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			px := color.NRGBA64Model.Convert(src.At(x, y)).(color.NRGBA64)
+			px.A = uint16(float64(px.A) * alpha)
+			dst.Set(x, y, px)
+		}
+	}
+}
+
+// textFilter implements gift.Filter, drawing left-aligned, word-wrapped
+// text using the standard library's embedded basicfont face - enough to
+// burn a caption or watermark into an image without pulling in a font
+// rendering dependency.
+type textFilter struct {
+	text    string
+	options map[string]any
+}
+
+func (f *textFilter) Bounds(srcBounds image.Rectangle) image.Rectangle { return srcBounds }
+
+func (f *textFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	draw.Draw(dst, src.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	b := src.Bounds()
+	x, _ := f.options["x"].(int)
+	y, ySet := f.options["y"].(int)
+	if !ySet {
+		y = b.Max.Y - 10
+	}
+
+	col := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	if hex, ok := f.options["color"].(string); ok {
+		if parsed := HexStringsToColors(hex); len(parsed) == 1 {
+			r, g, bl, _ := parsed[0].RGBA()
+			col = color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(bl >> 8), A: 0xff}
+		}
+	}
+
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+	}
+
+	const lineHeight = 16
+	maxWidth := fixed.I(b.Dx())
+
+	var line string
+	flush := func() {
+		if line == "" {
+			return
+		}
+		d.Dot = fixed.P(x, y)
+		d.DrawString(line)
+		y += lineHeight
+		line = ""
+	}
+	for _, word := range strings.Fields(f.text) {
+		trial := word
+		if line != "" {
+			trial = line + " " + word
+		}
+		if line != "" && d.MeasureString(trial) > maxWidth {
+			flush()
+			trial = word
+		}
+		line = trial
+	}
+	flush()
+}
+
+// Filter applies one or more gift.Filter values (built via Filters, or any
+// other type implementing gift.Filter) to the image, in order. filterArgs
+// accepts either a single gift.Filter or a []gift.Filter so that both
+// img.Filter(f.Grayscale()) and img.Filter(filters[0:4]) type-check - a
+// variadic ...gift.Filter parameter would reject the latter call without an
+// explicit "..." spread.
+func (i *imageResource) Filter(filterArgs any) (ImageResource, error) {
+	var filters []gift.Filter
+	switch v := filterArgs.(type) {
+	case gift.Filter:
+		filters = []gift.Filter{v}
+	case []gift.Filter:
+		filters = v
+	default:
+		return nil, fmt.Errorf("images: Filter: unsupported argument type %T", filterArgs)
+	}
+
+	g := gift.New(filters...)
+	b := i.img.Bounds()
+	dst := image.NewNRGBA(g.Bounds(b))
+	g.Draw(dst, i.img)
+
+	result := newImageResource(i.spec, dst, i.name, i.ext, i.format, i.mediaType)
+	result.dir = i.dir
+	result.sourceContent = i.sourceContent
+	result.exifData = i.exifData
+
+	typeNames := make([]string, len(filters))
+	for idx, f := range filters {
+		typeNames[idx] = fmt.Sprintf("%T", f)
+	}
+	result.descriptiveSuffix = "_filter_" + strings.Join(typeNames, "_")
+	result.specKeyStr = i.specKey("filter", result.descriptiveSuffix)
+
+	return result, nil
+}
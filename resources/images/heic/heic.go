@@ -0,0 +1,26 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package heic registers read-only HEIC/HEIF decoding, so a site can use a
+// phone's straight-off-the-camera HEIC photos as source assets and let Hugo
+// re-encode them to jpg/png/webp/avif on resize. There is intentionally no
+// HEIC encoder: it's a patent-encumbered, camera-oriented format that Hugo
+// has no reason to produce.
+package heic
+
+// Supports reports whether this build of Hugo can decode HEIC images. It's
+// false unless Hugo was built with the "avif" build tag, which pulls in the
+// same libheif-adjacent CGO toolchain used for AVIF.
+func Supports() bool {
+	return isHeicSupportEnabled
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build avif
+
+package heic
+
+import (
+	"image"
+	"io"
+
+	heifdec "github.com/strukturag/libheif/go/heif"
+)
+
+const isHeicSupportEnabled = true
+
+func init() {
+	image.RegisterFormat("heic", "????ftypheic", Decode, DecodeConfig)
+	image.RegisterFormat("heif", "????ftypmif1", Decode, DecodeConfig)
+}
+
+// Decode reads a HEIC/HEIF image from r.
+func Decode(r io.Reader) (image.Image, error) {
+	return heifdec.DecodeReader(r)
+}
+
+// DecodeConfig returns the color model and dimensions of a HEIC/HEIF image
+// without decoding the whole thing, as required by image.RegisterFormat.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	return heifdec.DecodeConfigReader(r)
+}
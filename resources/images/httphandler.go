@@ -0,0 +1,185 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxOutputPixels is the ThumbnailHandler default for MaxOutputPixels:
+// large enough for any sane responsive-image variant, small enough that
+// ?w=99999&h=99999 can't be used to force Hugo to allocate gigabytes per
+// request.
+const DefaultMaxOutputPixels = 64_000_000 // e.g. 8000x8000
+
+// allowedThumbnailParams is the strict allow-list of query parameters the
+// on-demand thumbnail endpoint understands. A request with any other query
+// parameter is rejected with 400 Bad Request, so it can't be abused to reach
+// code paths the handler doesn't intend to expose.
+var allowedThumbnailParams = map[string]bool{
+	"w": true, "h": true, "fit": true, "fmt": true, "q": true, "gravity": true,
+}
+
+// readSeekCloser is implemented by the concrete ImageResource returned from
+// Process; it lets the handler stream the generated bytes without knowing
+// how they were produced. When imaging.cacheDir is configured,
+// ReadSeekCloser populates the same on-disk cache a template-triggered
+// transform would (see image.go's ReadSeekCloser), so a variant requested
+// once through this handler is read straight off disk the next time
+// anything - this handler again, or a template - asks for it.
+type readSeekCloser interface {
+	ReadSeekCloser() (io.ReadSeekCloser, error)
+}
+
+// ThumbnailHandler serves on-demand image transforms for `hugo server`,
+// e.g. GET /_hugo/image/photos/lake.jpg?w=300&h=200&fit=fill&fmt=webp&q=70.
+// It's a development convenience for prototyping responsive layouts without
+// pre-declaring every variant in templates; Resolve is expected to look the
+// path up among the site's image resources (see assets under `assets/`).
+type ThumbnailHandler struct {
+	// Resolve returns the source ImageResource for the path component of a
+	// request, e.g. "photos/lake.jpg".
+	Resolve func(path string) (ImageResource, error)
+
+	// MaxOutputPixels bounds w*h. Requests asking for more are rejected with
+	// 400 Bad Request rather than honoured, to prevent trivial DoS via
+	// oversized dimensions. Defaults to DefaultMaxOutputPixels.
+	MaxOutputPixels int
+}
+
+func (h *ThumbnailHandler) maxOutputPixels() int {
+	if h.MaxOutputPixels > 0 {
+		return h.MaxOutputPixels
+	}
+	return DefaultMaxOutputPixels
+}
+
+// maxDimension bounds a single width or height on its own, so that a request
+// supplying only one of w/h (the common case) can't bypass the width*height
+// guard by leaving the other at its zero value, e.g. ?w=999999999.
+func (h *ThumbnailHandler) maxDimension() int {
+	return int(math.Sqrt(float64(h.maxOutputPixels())))
+}
+
+func (h *ThumbnailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/_hugo/image/")
+
+	query := r.URL.Query()
+	for key := range query {
+		if !allowedThumbnailParams[key] {
+			http.Error(w, fmt.Sprintf("unsupported query parameter %q", key), http.StatusBadRequest)
+			return
+		}
+	}
+
+	spec, width, height, err := buildThumbnailSpec(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	maxDim := h.maxDimension()
+	if width > maxDim || height > maxDim || (width > 0 && height > 0 && width*height > h.maxOutputPixels()) {
+		http.Error(w, fmt.Sprintf("requested output of %dx%d exceeds the maximum of %d pixels", width, height, h.maxOutputPixels()), http.StatusBadRequest)
+		return
+	}
+
+	img, err := h.Resolve(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	processed, err := img.Process(spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rsc, ok := processed.(readSeekCloser)
+	if !ok {
+		http.Error(w, "thumbnail backend does not support streaming reads", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := rsc.ReadSeekCloser()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", processed.MediaType().String())
+	w.Header().Set("Cache-Control", "no-store")
+	io.Copy(w, f)
+}
+
+// buildThumbnailSpec turns the allow-listed query parameters into a
+// Process-style spec string, returning the requested width/height so the
+// caller can apply the output-pixel guard before doing any work.
+func buildThumbnailSpec(query map[string][]string) (spec string, width, height int, err error) {
+	get := func(key string) string {
+		if v := query[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	width, err = parseNonNegativeInt(get("w"))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid w: %w", err)
+	}
+	height, err = parseNonNegativeInt(get("h"))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid h: %w", err)
+	}
+	if width == 0 && height == 0 {
+		return "", 0, 0, fmt.Errorf("at least one of w or h is required")
+	}
+
+	action := get("fit")
+	if action == "" {
+		action = ActionFit
+	}
+
+	parts := []string{fmt.Sprintf("%dx%d", width, height)}
+	if gravity := get("gravity"); gravity != "" {
+		parts = append(parts, gravity)
+	}
+	if format := get("fmt"); format != "" {
+		parts = append(parts, format)
+	}
+	if q := get("q"); q != "" {
+		parts = append(parts, "q"+q)
+	}
+	parts = append(parts, action)
+
+	return strings.Join(parts, " "), width, height, nil
+}
+
+func parseNonNegativeInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("must be a non-negative integer, got %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestBuildThumbnailSpec(t *testing.T) {
+	c := qt.New(t)
+
+	spec, w, h, err := buildThumbnailSpec(url.Values{
+		"w": {"300"}, "h": {"200"}, "fit": {"fill"}, "fmt": {"webp"}, "q": {"70"}, "gravity": {"smart"},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(w, qt.Equals, 300)
+	c.Assert(h, qt.Equals, 200)
+	c.Assert(spec, qt.Equals, "300x200 smart webp q70 fill")
+}
+
+func TestBuildThumbnailSpecRequiresDimension(t *testing.T) {
+	c := qt.New(t)
+
+	_, _, _, err := buildThumbnailSpec(url.Values{})
+	c.Assert(err, qt.Not(qt.IsNil))
+}
+
+func TestThumbnailHandlerServeHTTPRejectsUnknownParam(t *testing.T) {
+	c := qt.New(t)
+
+	h := &ThumbnailHandler{Resolve: func(string) (ImageResource, error) { return nil, nil }}
+	req := httptest.NewRequest(http.MethodGet, "/_hugo/image/photos/lake.jpg?w=300&exec=1", nil)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	c.Assert(rec.Code, qt.Equals, http.StatusBadRequest)
+}
+
+func TestThumbnailHandlerMaxOutputPixelsDefault(t *testing.T) {
+	c := qt.New(t)
+
+	h := &ThumbnailHandler{}
+	c.Assert(h.maxOutputPixels(), qt.Equals, DefaultMaxOutputPixels)
+
+	h.MaxOutputPixels = 100
+	c.Assert(h.maxOutputPixels(), qt.Equals, 100)
+}
+
+// TestThumbnailHandlerServeHTTPRejectsOversizedSingleDimension guards against
+// the width*height check being bypassed by leaving one dimension at its zero
+// value, e.g. ?w=999999999 with no h.
+func TestThumbnailHandlerServeHTTPRejectsOversizedSingleDimension(t *testing.T) {
+	c := qt.New(t)
+
+	h := &ThumbnailHandler{
+		Resolve:         func(string) (ImageResource, error) { return nil, nil },
+		MaxOutputPixels: 100 * 100,
+	}
+	req := httptest.NewRequest(http.MethodGet, "/_hugo/image/photos/lake.jpg?w=999999999", nil)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	c.Assert(rec.Code, qt.Equals, http.StatusBadRequest)
+}
+
+func TestThumbnailHandlerServeHTTPServesProcessedImage(t *testing.T) {
+	c := qt.New(t)
+
+	spec, err := NewSpec(ImagingConfig{})
+	c.Assert(err, qt.IsNil)
+	img := newTestImageResource(c, spec)
+
+	h := &ThumbnailHandler{Resolve: func(string) (ImageResource, error) { return img, nil }}
+	req := httptest.NewRequest(http.MethodGet, "/_hugo/image/test.png?w=100&h=75&fit=fill", nil)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	c.Assert(rec.Code, qt.Equals, http.StatusOK)
+	c.Assert(rec.Body.Len() > 0, qt.IsTrue)
+}
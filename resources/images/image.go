@@ -0,0 +1,391 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohugoio/hugo/media"
+)
+
+// ImageResource represents an image that can be transformed and whose
+// result can itself be transformed again, e.g. image.Resize("300x200").Fit("50x50").
+type ImageResource interface {
+	Width() int
+	Height() int
+	Name() string
+	MediaType() media.Type
+
+	// RelPermalink is where the image is (or, for a not-yet-published
+	// transform result, will be) served from, rooted at the site. Its
+	// basename is derived from Name and, once a transform has been applied,
+	// the configured FilenameStrategy. See filenamescheme.go.
+	RelPermalink() string
+
+	// ResourceType is the Hugo resource type, "image", for every
+	// ImageResource - kept as a method rather than a constant so
+	// ImageResource alone satisfies the same duck-typed interface
+	// templates use for every other Resource kind.
+	ResourceType() string
+
+	// Colors returns the image's most common colors, most common first. See
+	// colors.go.
+	Colors() ([]Color, error)
+
+	// Exif returns the source image's EXIF metadata, or nil if it has none
+	// (or isn't a JPEG). A transform's result carries its source's Exif
+	// forward unchanged. See exif.go.
+	Exif() *Exif
+
+	// Filter applies one or more filters built from Filters, e.g.
+	// img.Filter(Filters{}.Grayscale()) or img.Filter(filters[0:4]). See
+	// filters.go.
+	Filter(filterArgs any) (ImageResource, error)
+
+	Resize(spec string) (ImageResource, error)
+	Fit(spec string) (ImageResource, error)
+	Fill(spec string) (ImageResource, error)
+	Crop(spec string) (ImageResource, error)
+	Process(spec string) (ImageResource, error)
+
+	// Thumbnails returns the image resized according to every preset
+	// declared in imaging.thumbnails. See thumbnails.go.
+	Thumbnails() ([]ImageResource, error)
+	Thumbnail(name string) (ImageResource, error)
+}
+
+// imageResource is the concrete ImageResource every transform in this
+// package produces and consumes.
+type imageResource struct {
+	spec *Spec
+
+	img image.Image
+
+	name string
+	ext  string
+
+	width  int
+	height int
+
+	// format is the encode format ("png", "jpg", "webp", ...), kept
+	// alongside mediaType so ReadSeekCloser knows which Backend.Encode
+	// branch to use without having to reverse-parse media.Type.
+	format    string
+	mediaType media.Type
+
+	// dir is the RelPermalink directory this resource (and everything
+	// transformed from it) is served under, e.g. "/a/". Set by NewImage;
+	// zero value is fine for resources built directly via newImageResource
+	// in this package's own tests, which don't exercise RelPermalink.
+	dir string
+
+	// sourceContent is the original, undecoded source bytes, set by
+	// NewImage and carried forward by every transform. Its MD5 and length
+	// go into every transform result's RelPermalink basename, whatever the
+	// FilenameStrategy, and it's what decodeExif parses.
+	sourceContent []byte
+
+	// exifData is the source image's parsed EXIF metadata (nil if it has
+	// none), carried forward by every transform unchanged - a resize
+	// doesn't change when or where a photo was taken.
+	exifData *Exif
+
+	// descriptiveSuffix and specKeyStr are empty for an untransformed
+	// resource (RelPermalink is then just dir+name) and set by doTransform
+	// and Filter for a transform result; see RelPermalink and
+	// filenamescheme.go.
+	descriptiveSuffix string
+	specKeyStr        string
+}
+
+func newImageResource(spec *Spec, img image.Image, name, ext, format string, mediaType media.Type) *imageResource {
+	b := img.Bounds()
+	return &imageResource{
+		spec:      spec,
+		img:       img,
+		name:      name,
+		ext:       ext,
+		width:     b.Dx(),
+		height:    b.Dy(),
+		format:    format,
+		mediaType: mediaType,
+	}
+}
+
+// NewImage decodes r into an ImageResource named name (its final path
+// element, e.g. "sunset.jpg"), served from dir (e.g. "/a/"), backed by
+// spec's configured backend. It's the entry point hugolib uses to turn a
+// real file discovered under assets/ or a page bundle into an
+// ImageResource; newImageResource (unexported) only wraps an
+// already-decoded image.Image, which is all this package's own tests need.
+func NewImage(r io.Reader, dir, name string, spec *Spec) (ImageResource, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("images: NewImage %q: %w", name, err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("images: NewImage %q: %w", name, err)
+	}
+
+	ext := filepath.Ext(name)
+	result := newImageResource(spec, img, name, ext, format, mediaTypeForFormat(format, ext))
+	result.dir = dir
+	result.sourceContent = content
+	result.exifData = decodeExif(content)
+
+	return result, nil
+}
+
+// mediaTypeForFormat builds the media.Type for an image.Decode format
+// string (falling back to ext when format is empty, e.g. for formats
+// registered without image.RegisterFormat's human name), normalizing "jpg"
+// to the canonical "jpeg" subtype.
+func mediaTypeForFormat(format, ext string) media.Type {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	return media.Type{Type: "image/" + format}
+}
+
+func (i *imageResource) getSpec() *Spec { return i.spec }
+
+func (i *imageResource) Width() int            { return i.width }
+func (i *imageResource) Height() int           { return i.height }
+func (i *imageResource) Name() string          { return i.name }
+func (i *imageResource) MediaType() media.Type { return i.mediaType }
+
+// ResourceType identifies this as an "image" resource, same as every other
+// ImageResource regardless of its encode format.
+func (i *imageResource) ResourceType() string { return "image" }
+
+// DecodeImage returns the already-decoded image, satisfying ImageSource so
+// an ImageResource can be passed directly to Filters.Overlay.
+func (i *imageResource) DecodeImage() (image.Image, error) { return i.img, nil }
+
+// Exif returns the source image's parsed EXIF metadata, or nil if it has
+// none.
+func (i *imageResource) Exif() *Exif { return i.exifData }
+
+// RelPermalink is dir+name, unadorned, for an untransformed resource; once a
+// transform has run (descriptiveSuffix is non-empty), the basename is built
+// by transformedFilename according to the owning Spec's FilenameStrategy -
+// see filenamescheme.go. It's the same basename ReadSeekCloser caches the
+// encoded bytes under on disk, so the two always agree on where a given
+// transform lives.
+func (i *imageResource) RelPermalink() string {
+	base, err := i.cacheBasename()
+	if err != nil {
+		// Encoding only fails for the content strategy (it needs the final
+		// bytes); fall back to descriptive so RelPermalink still returns
+		// something rather than propagating an error through an interface
+		// method that doesn't have one to give.
+		base, _ = i.cacheBasenameWithStrategy(FilenameStrategyDescriptive)
+	}
+	return i.dir + base
+}
+
+// cacheBasename is cacheBasenameWithStrategy using the owning Spec's
+// configured FilenameStrategy.
+func (i *imageResource) cacheBasename() (string, error) {
+	strategy := FilenameStrategyDescriptive
+	if i.spec != nil && i.spec.imaging.FilenameStrategy != "" {
+		strategy = FilenameStrategy(i.spec.imaging.FilenameStrategy)
+	}
+	return i.cacheBasenameWithStrategy(strategy)
+}
+
+// cacheBasenameWithStrategy builds this resource's filename - both its
+// RelPermalink basename and its on-disk cache key - under strategy. For an
+// untransformed resource (descriptiveSuffix empty) it's always just the
+// original name, regardless of strategy: there's no transform to describe,
+// hash or make immutable.
+func (i *imageResource) cacheBasenameWithStrategy(strategy FilenameStrategy) (string, error) {
+	if i.descriptiveSuffix == "" {
+		return i.name, nil
+	}
+
+	base := strings.TrimSuffix(i.name, i.ext)
+	if len(i.sourceContent) > 0 {
+		sum := md5.Sum(i.sourceContent)
+		base = fmt.Sprintf("%s_hu%x_%d", base, sum, len(i.sourceContent))
+	}
+
+	var content []byte
+	if strategy == FilenameStrategyContent {
+		encoded, err := i.encode()
+		if err != nil {
+			return "", err
+		}
+		content = encoded
+	}
+
+	return transformedFilename(strategy, base, i.ext, i.descriptiveSuffix, i.specKeyStr, content), nil
+}
+
+func (i *imageResource) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := i.spec.backend.Encode(&buf, i.img, i.format, 75); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadSeekCloser lets callers such as ThumbnailHandler and hugolib's
+// template-facing Resource stream the transformed bytes, without either
+// knowing how they were produced, from the on-disk cache configured via
+// imaging.cacheDir - encoding and writing it there first on a miss - or,
+// with no cache dir configured, by encoding fresh on every call as before.
+func (i *imageResource) ReadSeekCloser() (io.ReadSeekCloser, error) {
+	cacheDir := ""
+	if i.spec != nil {
+		cacheDir = i.spec.imaging.CacheDir
+	}
+	if cacheDir == "" {
+		content, err := i.encode()
+		if err != nil {
+			return nil, err
+		}
+		return nopSeekCloser{bytes.NewReader(content)}, nil
+	}
+
+	base, err := i.cacheBasename()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := filepath.Join(cacheDir, base)
+
+	if f, err := os.Open(cachePath); err == nil {
+		return f, nil
+	}
+
+	content, err := i.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err == nil {
+		// Best-effort: a failed write (full disk, permissions, a
+		// concurrent build cleaning the dir) shouldn't fail the request
+		// that triggered it, just mean it's recomputed next time too.
+		_ = os.WriteFile(cachePath, content, 0o644)
+	}
+
+	return nopSeekCloser{bytes.NewReader(content)}, nil
+}
+
+type nopSeekCloser struct{ *bytes.Reader }
+
+func (nopSeekCloser) Close() error { return nil }
+
+// specKey uniquely identifies a transform of this resource by its owning
+// Spec, source content, name and the action+spec requested. It's what the
+// shared transform pool in workerpool.go deduplicates concurrent identical
+// calls on, and (via FilenameStrategyHash) what the hash filename strategy
+// derives its short names from.
+//
+// The Spec pointer is folded in, not just the name, because the transform
+// pool is a single process-wide instance: without it, two different sites
+// (or two page bundles) that each have their own "featured.jpg" and request
+// the same resize concurrently would dedup-hit on each other and the second
+// caller would get back the first caller's encoded bytes under its own
+// name. The source content is folded in too, for the same reason one level
+// down: two bundles in the *same* site can each have their own
+// "featured.jpg" with different bytes, and the Spec pointer alone can't
+// tell those apart.
+func (i *imageResource) specKey(action, spec string) string {
+	h := md5.New()
+	fmt.Fprintf(h, "%p|%s|%s|%s|", i.spec, i.name, action, spec)
+	h.Write(i.sourceContent)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// doTransform runs the backend operation for action/spec synchronously; it
+// is always called through transform() below so that it's both bounded by
+// the shared worker pool and deduplicated across identical concurrent
+// requests.
+func (i *imageResource) doTransform(action, spec string) (ImageResource, error) {
+	width, height, filter, anchor, format := parseSpec(spec)
+
+	out, err := i.spec.backend.Resize(i.img, action, width, height, filter, anchor)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", action, spec, err)
+	}
+
+	outFormat := i.format
+	mediaType := i.mediaType
+	if format != "" {
+		outFormat = format
+		mediaType = media.Type{Type: "image/" + format}
+	}
+
+	result := newImageResource(i.spec, out, i.name, i.ext, outFormat, mediaType)
+	result.dir = i.dir
+	result.sourceContent = i.sourceContent
+	result.exifData = i.exifData
+	result.descriptiveSuffix = descriptiveSuffix(action, spec)
+	result.specKeyStr = i.specKey(action, spec)
+
+	return result, nil
+}
+
+func (i *imageResource) transform(action, spec string) (ImageResource, error) {
+	key := i.specKey(action, spec)
+	return transform(key, func() (ImageResource, error) {
+		return i.doTransform(action, spec)
+	})
+}
+
+func (i *imageResource) Resize(spec string) (ImageResource, error) {
+	return i.transform(ActionResize, spec)
+}
+func (i *imageResource) Fit(spec string) (ImageResource, error) { return i.transform(ActionFit, spec) }
+func (i *imageResource) Fill(spec string) (ImageResource, error) {
+	return i.transform(ActionFill, spec)
+}
+func (i *imageResource) Crop(spec string) (ImageResource, error) {
+	return i.transform(ActionCrop, spec)
+}
+
+// Process accepts a space-separated spec whose last recognized action word
+// (resize, fit, fill or crop) selects the operation; it defaults to resize
+// when none is present, e.g. Process("300x200") behaves like
+// Resize("300x200").
+func (i *imageResource) Process(spec string) (ImageResource, error) {
+	action := ActionResize
+	fields := strings.Fields(spec)
+	rest := fields[:0:0]
+	rest = append(rest, fields...)
+
+	for idx, f := range fields {
+		switch strings.ToLower(f) {
+		case ActionResize, ActionFit, ActionFill, ActionCrop:
+			action = strings.ToLower(f)
+			rest = append(append([]string{}, fields[:idx]...), fields[idx+1:]...)
+		}
+	}
+
+	return i.transform(action, strings.Join(rest, " "))
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func encodeTestPNG(c *qt.C, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	var buf bytes.Buffer
+	c.Assert(png.Encode(&buf, img), qt.IsNil)
+	return buf.Bytes()
+}
+
+// TestNewImageEndToEnd exercises the public decode entry point real source
+// files go through (unlike newImageResource, which every other test in this
+// package uses to build a resource around an image.Image it already has in
+// hand): decode real bytes, transform the result, and check RelPermalink
+// reflects both the destination directory and the transform applied.
+func TestNewImageEndToEnd(t *testing.T) {
+	c := qt.New(t)
+
+	spec, err := NewSpec(ImagingConfig{})
+	c.Assert(err, qt.IsNil)
+
+	content := encodeTestPNG(c, 400, 300)
+
+	img, err := NewImage(bytes.NewReader(content), "/a/", "sunset.png", spec)
+	c.Assert(err, qt.IsNil)
+	c.Assert(img.Width(), qt.Equals, 400)
+	c.Assert(img.Height(), qt.Equals, 300)
+	c.Assert(img.ResourceType(), qt.Equals, "image")
+	c.Assert(img.RelPermalink(), qt.Equals, "/a/sunset.png")
+
+	resized, err := img.Resize("200x150")
+	c.Assert(err, qt.IsNil)
+	c.Assert(resized.Width(), qt.Equals, 200)
+	c.Assert(resized.RelPermalink(), qt.Not(qt.Equals), img.RelPermalink())
+	c.Assert(resized.RelPermalink(), qt.Contains, "sunset_hu")
+}
+
+func TestNewImageInvalid(t *testing.T) {
+	c := qt.New(t)
+
+	spec, err := NewSpec(ImagingConfig{})
+	c.Assert(err, qt.IsNil)
+
+	_, err = NewImage(bytes.NewReader([]byte("not an image")), "/a/", "broken.png", spec)
+	c.Assert(err, qt.Not(qt.IsNil))
+}
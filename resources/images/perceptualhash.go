@@ -0,0 +1,141 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// perceptualHashSize is the side length, in pixels, the source image is
+// downscaled to before the DCT is taken.
+const perceptualHashSize = 32
+
+// perceptualHashBits is the side length of the low-frequency block kept
+// from the DCT; perceptualHashBits*perceptualHashBits-1 (excluding the DC
+// term) bits make up the hash, rounded up to a 64-bit word below.
+const perceptualHashBits = 8
+
+// PerceptualHash computes a 64-bit perceptual hash (a DCT-based pHash) of
+// the image read from r. Unlike a byte- or MD5-hash of the encoded file,
+// two images that look alike hash to nearby values even when encoder
+// rounding differs slightly between platforms, e.g. the FMA
+// (fused-multiply-add) floating point differences some architectures
+// produce during resizing. Compare two hashes with HammingDistance.
+func PerceptualHash(r io.Reader) (uint64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("perceptual hash: decode: %w", err)
+	}
+
+	gray := downscaleToGray(img, perceptualHashSize, perceptualHashSize)
+	coeffs := dct2D(gray, perceptualHashSize)
+
+	// Keep only the top-left perceptualHashBits x perceptualHashBits block,
+	// which carries the low frequencies (the image's overall shape), and
+	// drop the single DC term (coeffs[0][0]), which only encodes average
+	// brightness.
+	var values []float64
+	for y := 0; y < perceptualHashBits; y++ {
+		for x := 0; x < perceptualHashBits; x++ {
+			if x == 0 && y == 0 {
+				continue
+			}
+			values = append(values, coeffs[y][x])
+		}
+	}
+
+	median := medianOf(values)
+
+	var hash uint64
+	for i, v := range values {
+		if v > median {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash, nil
+}
+
+// HammingDistance returns the number of bits that differ between a and b.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func downscaleToGray(img image.Image, w, h int) [][]float64 {
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+	out := make([][]float64, h)
+	for y := 0; y < h; y++ {
+		out[y] = make([]float64, w)
+		for x := 0; x < w; x++ {
+			out[y][x] = float64(dst.GrayAt(x, y).Y)
+		}
+	}
+	return out
+}
+
+// dct2D computes a naive 2D discrete cosine transform (type II) of an n x n
+// matrix. n is small (perceptualHashSize) so the O(n^4) approach is fine for
+// a one-off test/comparison helper; it isn't used in the hot image
+// processing path.
+func dct2D(m [][]float64, n int) [][]float64 {
+	out := make([][]float64, n)
+	for i := range out {
+		out[i] = make([]float64, n)
+	}
+
+	for u := 0; u < n; u++ {
+		for v := 0; v < n; v++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				for x := 0; x < n; x++ {
+					sum += m[y][x] *
+						math.Cos((2*float64(x)+1)*float64(u)*math.Pi/(2*float64(n))) *
+						math.Cos((2*float64(y)+1)*float64(v)*math.Pi/(2*float64(n)))
+				}
+			}
+			cu, cv := 1.0, 1.0
+			if u == 0 {
+				cu = 1 / math.Sqrt2
+			}
+			if v == 0 {
+				cv = 1 / math.Sqrt2
+			}
+			out[v][u] = 0.25 * cu * cv * sum
+		}
+	}
+
+	return out
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
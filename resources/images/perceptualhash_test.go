@@ -0,0 +1,73 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func checkerboardPNG(c *qt.C, light, dark color.Gray) []byte {
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x/8+y/8)%2 == 0 {
+				img.SetGray(x, y, light)
+			} else {
+				img.SetGray(x, y, dark)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	c.Assert(png.Encode(&buf, img), qt.IsNil)
+	return buf.Bytes()
+}
+
+func TestPerceptualHashIdentical(t *testing.T) {
+	c := qt.New(t)
+
+	data := checkerboardPNG(c, color.Gray{Y: 250}, color.Gray{Y: 10})
+
+	h1, err := PerceptualHash(bytes.NewReader(data))
+	c.Assert(err, qt.IsNil)
+	h2, err := PerceptualHash(bytes.NewReader(data))
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(HammingDistance(h1, h2), qt.Equals, 0)
+}
+
+func TestPerceptualHashDistinguishesImages(t *testing.T) {
+	c := qt.New(t)
+
+	checker := checkerboardPNG(c, color.Gray{Y: 250}, color.Gray{Y: 10})
+
+	solid := image.NewGray(image.Rect(0, 0, 64, 64))
+	for i := range solid.Pix {
+		solid.Pix[i] = 128
+	}
+	var buf bytes.Buffer
+	c.Assert(png.Encode(&buf, solid), qt.IsNil)
+
+	h1, err := PerceptualHash(bytes.NewReader(checker))
+	c.Assert(err, qt.IsNil)
+	h2, err := PerceptualHash(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(HammingDistance(h1, h2) > 5, qt.IsTrue)
+}
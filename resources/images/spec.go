@@ -0,0 +1,67 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+// Spec bundles a site's decoded imaging configuration with the Backend it
+// selects. hugolib builds one Spec per site from the `imaging` config
+// section (see DecodeConfig) and uses it both to construct image resources
+// discovered under assets/ (and page bundles) and, via WarmupImages, to
+// pre-generate their declared thumbnail presets before template execution
+// starts.
+type Spec struct {
+	imaging ImagingConfig
+	backend Backend
+}
+
+// NewSpec builds a Spec from a decoded ImagingConfig, resolving its backend
+// selection up front so a typo in imaging.backend fails the build
+// immediately rather than on the first image resource.
+func NewSpec(conf ImagingConfig) (*Spec, error) {
+	b, err := SelectBackend(conf.Backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Spec{imaging: conf, backend: b}, nil
+}
+
+// WarmupImages pre-generates every preset declared in imaging.thumbnails
+// for imgs. hugolib calls this once, right after discovering the image
+// resources under assets/ and page bundles, before rendering any
+// templates, using the bounded worker pool in thumbnails.go so sites with
+// thousands of images don't spawn one goroutine per image.
+func (s *Spec) WarmupImages(imgs []ImageResource) error {
+	if len(s.imaging.Thumbnails.Presets) == 0 {
+		return nil
+	}
+
+	workers := s.imaging.MaxConcurrentTransforms
+	if workers == 0 {
+		workers = s.imaging.Thumbnails.Workers
+	}
+
+	return WarmupThumbnails(imgs, workers)
+}
+
+// PruneStaleCache removes cached transforms left behind by a previous build
+// under a different imaging.filenameStrategy than this Spec is configured
+// with. hugolib calls this once per site, right after building its Spec, so
+// a mid-project strategy change doesn't leave the old variants as permanent
+// orphans. It's a no-op when imaging.cacheDir isn't configured.
+func (s *Spec) PruneStaleCache() error {
+	strategy, err := DecodeFilenameStrategy(s.imaging.FilenameStrategy)
+	if err != nil {
+		return err
+	}
+	return PruneStaleCache(s.imaging.CacheDir, strategy)
+}
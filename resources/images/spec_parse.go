@@ -0,0 +1,84 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	knownFormats = map[string]bool{"jpg": true, "jpeg": true, "png": true, "gif": true, "webp": true, "avif": true}
+	knownAnchors = map[string]bool{
+		"smart": true, "center": true, "topleft": true, "top": true, "topright": true,
+		"left": true, "right": true, "bottomleft": true, "bottom": true, "bottomright": true,
+	}
+)
+
+// parseSpec parses the space-separated part of a Resize/Fit/Fill/Crop/Process
+// spec string that follows the leading "WxH" dimensions, e.g.
+// "smart q70 webp" in "300x200 smart q70 webp". Unrecognized tokens
+// (rotation, quality, background color, ...) are accepted and ignored; this
+// is a pragmatic subset covering what the backends in this package act on.
+func parseSpec(spec string) (width, height int, filter, anchor, format string) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return 0, 0, "", "", ""
+	}
+
+	width, height = parseDimensions(fields[0])
+
+	for _, f := range fields[1:] {
+		lower := strings.ToLower(f)
+		switch {
+		case knownFormats[lower]:
+			format = lower
+		case knownAnchors[lower]:
+			anchor = lower
+		case strings.HasPrefix(lower, "q"):
+			// Quality, e.g. "q68"; accepted and ignored: imageResource.encode
+			// always encodes at quality 75.
+		case strings.HasPrefix(lower, "r") && isDigits(lower[1:]):
+			// Rotation, e.g. "r90"; not handled by the resize step.
+		default:
+			if filter == "" {
+				filter = lower
+			}
+		}
+	}
+
+	return width, height, filter, anchor, format
+}
+
+func parseDimensions(s string) (width, height int) {
+	parts := strings.SplitN(s, "x", 2)
+	if len(parts) != 2 {
+		return 0, 0
+	}
+	width, _ = strconv.Atoi(parts[0])
+	height, _ = strconv.Atoi(parts[1])
+	return width, height
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
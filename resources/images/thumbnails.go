@@ -0,0 +1,180 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// defaultThumbnailWorkers is used when imaging.thumbnails.workers isn't set
+// or is <= 0.
+var defaultThumbnailWorkers = runtime.NumCPU()
+
+// ThumbnailPreset describes one named thumbnail variant, declared once in
+// site configuration, that gets generated for every discovered image
+// resource instead of being created lazily the first time a template calls
+// Resize, Fit, Fill or Crop.
+type ThumbnailPreset struct {
+	// Name is how the preset is referenced from templates, e.g.
+	// {{ with $img.Thumbnail "small" }}.
+	Name string
+
+	// Action is one of ActionResize, ActionFit, ActionFill or ActionCrop.
+	// Defaults to ActionFit.
+	Action string
+
+	Width  int
+	Height int
+
+	// Anchor is only meaningful for ActionFill and ActionCrop, e.g. "smart"
+	// or "center".
+	Anchor string
+
+	Filter  string
+	Format  string
+	Quality int
+}
+
+// toSpec renders the preset into the same space-separated spec string
+// accepted by ImageResource.Process.
+func (p ThumbnailPreset) toSpec() string {
+	action := p.Action
+	if action == "" {
+		action = ActionFit
+	}
+
+	spec := fmt.Sprintf("%dx%d", p.Width, p.Height)
+	for _, part := range []string{p.Anchor, p.Filter, p.Format} {
+		if part != "" {
+			spec += " " + part
+		}
+	}
+	if p.Quality > 0 {
+		spec += fmt.Sprintf(" q%d", p.Quality)
+	}
+
+	return spec + " " + action
+}
+
+// ThumbnailsConfig holds the imaging.thumbnails site configuration: the
+// presets to pre-generate and how much parallelism to use while doing so.
+type ThumbnailsConfig struct {
+	Presets []ThumbnailPreset
+
+	// Workers bounds the number of goroutines used to warm up the thumbnail
+	// cache at the start of a build. Defaults to runtime.NumCPU().
+	// Spec.WarmupImages uses imaging.maxConcurrentTransforms instead when
+	// that is set.
+	Workers int
+}
+
+// DecodeThumbnailsConfig decodes the imaging.thumbnails section of the site
+// configuration.
+func DecodeThumbnailsConfig(m map[string]any) (ThumbnailsConfig, error) {
+	var conf ThumbnailsConfig
+	if m == nil {
+		return conf, nil
+	}
+
+	if err := mapstructure.WeakDecode(m, &conf); err != nil {
+		return conf, fmt.Errorf("failed to decode imaging.thumbnails: %w", err)
+	}
+
+	for _, p := range conf.Presets {
+		if p.Name == "" {
+			return conf, fmt.Errorf("imaging.thumbnails: every preset needs a name")
+		}
+	}
+
+	if conf.Workers <= 0 {
+		conf.Workers = defaultThumbnailWorkers
+	}
+
+	return conf, nil
+}
+
+// Thumbnails returns the image resized according to every preset declared in
+// imaging.thumbnails, in declaration order. It returns nil if no presets are
+// configured.
+func (i *imageResource) Thumbnails() ([]ImageResource, error) {
+	presets := i.getSpec().imaging.Thumbnails.Presets
+	if len(presets) == 0 {
+		return nil, nil
+	}
+
+	result := make([]ImageResource, len(presets))
+	for idx, p := range presets {
+		img, err := i.Process(p.toSpec())
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail %q: %w", p.Name, err)
+		}
+		result[idx] = img
+	}
+
+	return result, nil
+}
+
+// Thumbnail returns the named preset declared in imaging.thumbnails. It
+// errors if no preset with that name exists.
+func (i *imageResource) Thumbnail(name string) (ImageResource, error) {
+	presets := i.getSpec().imaging.Thumbnails.Presets
+	for _, p := range presets {
+		if p.Name == name {
+			return i.Process(p.toSpec())
+		}
+	}
+	return nil, fmt.Errorf("no thumbnail preset named %q", name)
+}
+
+// WarmupThumbnails pre-generates every configured thumbnail preset for imgs
+// using a worker pool bounded to workers goroutines, so builds with
+// thousands of images don't spawn one goroutine per image. It returns the
+// first error encountered, if any, after all work has finished.
+func WarmupThumbnails(imgs []ImageResource, workers int) error {
+	if workers <= 0 {
+		workers = defaultThumbnailWorkers
+	}
+
+	sem := make(chan struct{}, workers)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for _, img := range imgs {
+		img := img
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := img.Thumbnails(); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDecodeThumbnailsConfig(t *testing.T) {
+	c := qt.New(t)
+
+	conf, err := DecodeThumbnailsConfig(map[string]any{
+		"workers": 4,
+		"presets": []map[string]any{
+			{"name": "small", "width": 300, "height": 200, "action": "fill", "anchor": "smart"},
+			{"name": "large", "width": 1200},
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(conf.Workers, qt.Equals, 4)
+	c.Assert(len(conf.Presets), qt.Equals, 2)
+	c.Assert(conf.Presets[0].toSpec(), qt.Equals, "300x200 smart fill")
+	c.Assert(conf.Presets[1].toSpec(), qt.Equals, "1200x0 fit")
+}
+
+func TestDecodeThumbnailsConfigDefaultWorkers(t *testing.T) {
+	c := qt.New(t)
+
+	conf, err := DecodeThumbnailsConfig(map[string]any{
+		"presets": []map[string]any{{"name": "small", "width": 300}},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(conf.Workers > 0, qt.IsTrue)
+}
+
+func TestDecodeThumbnailsConfigNameRequired(t *testing.T) {
+	c := qt.New(t)
+
+	_, err := DecodeThumbnailsConfig(map[string]any{
+		"presets": []map[string]any{{"width": 300}},
+	})
+	c.Assert(err, qt.Not(qt.IsNil))
+}
+
+func TestDecodeThumbnailsConfigEmpty(t *testing.T) {
+	c := qt.New(t)
+
+	conf, err := DecodeThumbnailsConfig(nil)
+	c.Assert(err, qt.IsNil)
+	c.Assert(conf.Presets, qt.IsNil)
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"image"
+	"sync"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/gohugoio/hugo/media"
+)
+
+// TestImageResourceResizeRoutesThroughTransformPool proves that
+// imageResource.Resize (and, by the same transform() call, Fit/Fill/Crop/
+// Process) actually goes through the shared worker pool rather than just
+// calling the backend directly: many goroutines asking for the exact same
+// resize of the exact same source should produce exactly one dedup miss and
+// the rest dedup hits.
+func TestImageResourceResizeRoutesThroughTransformPool(t *testing.T) {
+	c := qt.New(t)
+
+	SetTransformWorkers(2)
+
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 300))
+	spec, err := NewSpec(ImagingConfig{})
+	c.Assert(err, qt.IsNil)
+	img := newImageResource(spec, src, "test.png", ".png", "png", media.Type{Type: "image/png"})
+
+	before := TransformMetrics().DedupHits
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resized, err := img.Resize("100x75")
+			c.Check(err, qt.IsNil)
+			c.Check(resized.Width(), qt.Equals, 100)
+			c.Check(resized.Height(), qt.Equals, 75)
+		}()
+	}
+	wg.Wait()
+
+	after := TransformMetrics().DedupHits
+	c.Assert(after-before >= 19, qt.IsTrue)
+}
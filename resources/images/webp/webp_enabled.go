@@ -0,0 +1,45 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build webp
+
+package webp
+
+import (
+	"image"
+	"io"
+
+	webpenc "github.com/chai2010/webp"
+)
+
+const isWebpSupportEnabled = true
+
+func init() {
+	image.RegisterFormat("webp", "RIFF????WEBP", Decode, DecodeConfig)
+}
+
+// Encode writes img to w as WebP at the given quality (1-100).
+func Encode(w io.Writer, img image.Image, quality int) error {
+	return webpenc.Encode(w, img, &webpenc.Options{Quality: float32(quality)})
+}
+
+// Decode reads a WebP image from r.
+func Decode(r io.Reader) (image.Image, error) {
+	return webpenc.Decode(r)
+}
+
+// DecodeConfig returns the color model and dimensions of a WebP image
+// without decoding the whole thing, as required by image.RegisterFormat.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	return webpenc.DecodeConfig(r)
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultTransformWorkers bounds the number of transforms (decode + process
+// + encode) that run at once when imaging.maxConcurrentTransforms isn't
+// configured.
+var defaultTransformWorkers = runtime.GOMAXPROCS(0)
+
+// transformCall tracks one in-flight Resize/Fill/Crop/Process call so that
+// concurrent callers asking for the exact same transform of the exact same
+// source share a single computation instead of each doing the work and
+// racing to populate the cache.
+type transformCall struct {
+	wg  sync.WaitGroup
+	res ImageResource
+	err error
+}
+
+// transformPool serializes transform work through a bounded number of
+// workers and deduplicates concurrent identical requests. A build with
+// hundreds of large source images being transformed at once would otherwise
+// be free to spawn one goroutine per call, risking an OOM; this caps memory
+// use to roughly workers-many transforms in flight.
+type transformPool struct {
+	sem chan struct{}
+
+	// workers is the resolved (post-default) worker count this pool was
+	// built with, so SetTransformWorkers can tell a genuine reconfiguration
+	// apart from a repeat decode of the same value - see SetTransformWorkers.
+	workers int
+
+	mu       sync.Mutex
+	inflight map[string]*transformCall
+
+	queued    int64
+	running   int64
+	dedupHits int64
+}
+
+func newTransformPool(workers int) *transformPool {
+	if workers <= 0 {
+		workers = defaultTransformWorkers
+	}
+	return &transformPool{
+		sem:      make(chan struct{}, workers),
+		workers:  workers,
+		inflight: make(map[string]*transformCall),
+	}
+}
+
+// TransformPoolMetrics is a point-in-time snapshot of a transformPool's
+// activity, exposed so `hugo server`'s admin endpoints or build summaries
+// can report on it.
+type TransformPoolMetrics struct {
+	// Queued is the number of transforms waiting for a free worker slot.
+	Queued int64
+	// InFlight is the number of transforms currently running.
+	InFlight int64
+	// DedupHits is the number of calls that were satisfied by an
+	// already-running identical transform instead of doing their own work.
+	DedupHits int64
+}
+
+// Metrics returns a snapshot of the pool's current activity.
+func (p *transformPool) Metrics() TransformPoolMetrics {
+	return TransformPoolMetrics{
+		Queued:    atomic.LoadInt64(&p.queued),
+		InFlight:  atomic.LoadInt64(&p.running),
+		DedupHits: atomic.LoadInt64(&p.dedupHits),
+	}
+}
+
+// Do runs fn, keyed by key, through the pool. If another goroutine is
+// already running fn for the same key, Do waits for that call to finish and
+// returns its result instead of running fn again.
+func (p *transformPool) Do(key string, fn func() (ImageResource, error)) (ImageResource, error) {
+	p.mu.Lock()
+	if c, found := p.inflight[key]; found {
+		p.mu.Unlock()
+		atomic.AddInt64(&p.dedupHits, 1)
+		c.wg.Wait()
+		return c.res, c.err
+	}
+
+	c := &transformCall{}
+	c.wg.Add(1)
+	p.inflight[key] = c
+	p.mu.Unlock()
+
+	atomic.AddInt64(&p.queued, 1)
+	p.sem <- struct{}{}
+	atomic.AddInt64(&p.queued, -1)
+	atomic.AddInt64(&p.running, 1)
+
+	c.res, c.err = fn()
+
+	atomic.AddInt64(&p.running, -1)
+	<-p.sem
+
+	p.mu.Lock()
+	delete(p.inflight, key)
+	p.mu.Unlock()
+	c.wg.Done()
+
+	return c.res, c.err
+}
+
+var (
+	globalTransformPoolMu sync.RWMutex
+	globalTransformPool   = newTransformPool(defaultTransformWorkers)
+)
+
+// SetTransformWorkers reconfigures the shared transform pool's worker
+// count. Hugo calls this once per site's imaging.maxConcurrentTransforms
+// config, which in a
+// multilingual/multi-site build means it can run several times in one
+// process; if every call swapped in a brand new pool regardless, the second
+// site's decode would silently drop the first site's in-flight dedup calls
+// and zero out its metrics. So a call that resolves to the same worker count
+// the pool already has is a no-op instead of a replacement.
+func SetTransformWorkers(n int) {
+	globalTransformPoolMu.Lock()
+	defer globalTransformPoolMu.Unlock()
+	resolved := n
+	if resolved <= 0 {
+		resolved = defaultTransformWorkers
+	}
+	if globalTransformPool != nil && globalTransformPool.workers == resolved {
+		return
+	}
+	globalTransformPool = newTransformPool(n)
+}
+
+// transform runs fn through the shared, bounded transform pool, deduplicating
+// concurrent calls that share the same spec key.
+func transform(specKey string, fn func() (ImageResource, error)) (ImageResource, error) {
+	globalTransformPoolMu.RLock()
+	pool := globalTransformPool
+	globalTransformPoolMu.RUnlock()
+	return pool.Do(specKey, fn)
+}
+
+// TransformMetrics returns a snapshot of the shared transform pool's
+// current activity.
+func TransformMetrics() TransformPoolMetrics {
+	globalTransformPoolMu.RLock()
+	pool := globalTransformPool
+	globalTransformPoolMu.RUnlock()
+	return pool.Metrics()
+}
+
+// ImagingConfig.MaxConcurrentTransforms is applied by DecodeConfig and by
+// DecodeMaxConcurrentTransforms below; documented here since it's the
+// config-facing name for SetTransformWorkers.
+//
+//	[imaging]
+//	  maxConcurrentTransforms = 8
+
+// DecodeMaxConcurrentTransforms reads imaging.maxConcurrentTransforms from
+// site configuration and applies it to the shared transform pool. A value
+// of 0 (or the key being absent) keeps the runtime.GOMAXPROCS(0) default.
+func DecodeMaxConcurrentTransforms(maxConcurrentTransforms int) {
+	SetTransformWorkers(maxConcurrentTransforms)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestTransformPoolDeduplicates(t *testing.T) {
+	c := qt.New(t)
+
+	pool := newTransformPool(4)
+
+	var calls int32
+	var start sync.WaitGroup
+	start.Add(1)
+
+	fn := func() (ImageResource, error) {
+		atomic.AddInt32(&calls, 1)
+		start.Wait()
+		return nil, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.Do("same-key", fn)
+			c.Check(err, qt.IsNil)
+		}()
+	}
+
+	start.Done()
+	wg.Wait()
+
+	c.Assert(atomic.LoadInt32(&calls), qt.Equals, int32(1))
+}
+
+func TestTransformPoolMetrics(t *testing.T) {
+	c := qt.New(t)
+
+	pool := newTransformPool(1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = pool.Do("same-key", func() (ImageResource, error) { return nil, nil })
+		}()
+	}
+	wg.Wait()
+
+	m := pool.Metrics()
+	c.Assert(m.Queued, qt.Equals, int64(0))
+	c.Assert(m.InFlight, qt.Equals, int64(0))
+	c.Assert(m.DedupHits >= int64(1), qt.IsTrue)
+}
+
+func TestTransformPoolBoundsConcurrency(t *testing.T) {
+	c := qt.New(t)
+
+	pool := newTransformPool(2)
+
+	var current, max int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = pool.Do(string(rune('a'+i)), func() (ImageResource, error) {
+				n := atomic.AddInt32(&current, 1)
+				for {
+					old := atomic.LoadInt32(&max)
+					if n <= old || atomic.CompareAndSwapInt32(&max, old, n) {
+						break
+					}
+				}
+				atomic.AddInt32(&current, -1)
+				return nil, nil
+			})
+		}(i)
+	}
+
+	wg.Wait()
+
+	c.Assert(max <= 2, qt.IsTrue)
+}